@@ -4,47 +4,283 @@
 // @host localhost:8080
 // @BasePath /
 // @schemes http
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
+// @description Ключ API в виде "Bearer <ключ>"
 package main
 
 import (
-	"github.com/joho/godotenv"
+	"context"
+	"errors"
+	"fmt"
 	"github.com/swaggo/http-swagger"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 	_ "tinvest_report/docs"
 
 	"tinvest_report/db"
+	"tinvest_report/internal/config"
 	"tinvest_report/internal/handlers"
+	"tinvest_report/internal/logging"
+	"tinvest_report/internal/metrics"
+	"tinvest_report/internal/scheduler"
 	"tinvest_report/internal/service"
 	"tinvest_report/internal/tasks"
+	"tinvest_report/internal/telegram"
+	"tinvest_report/internal/tracing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func main() {
-	err := godotenv.Load(filepath.Join(".", ".env"))
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		logging.Fatal("Некорректная конфигурация", "error", err)
+	}
+	migrate := len(args) > 0 && args[0] == "migrate"
+	// Подкоманде migrate нужна только БД, токены для неё не обязательны.
+	if !migrate {
+		if err := cfg.Validate(); err != nil {
+			logging.Fatal("Некорректная конфигурация", "error", err)
+		}
+	}
+	if err := logging.Setup(cfg.Log.Level, cfg.Log.Format); err != nil {
+		logging.Fatal("Ошибка настройки логирования", "error", err)
+	}
+	// Секреты скрываются во всех записях лога, в том числе в тексте ошибок: токен Telegram
+	// входит в URL Bot API и попадает в ошибки HTTP-клиента.
+	for _, secret := range []string{cfg.Tinkoff.Token, cfg.AdminToken, cfg.SMTP.Password, cfg.Telegram.Token} {
+		logging.AddSecret(secret)
+	}
+	if cfg.File != "" {
+		slog.Info("Настройки загружены", "file", cfg.File)
+	}
+	for _, w := range cfg.Warnings {
+		slog.Warn(w)
+	}
+
+	pool, err := db.NewPostgresDB(cfg.PostgresDSN)
+	if err != nil {
+		logging.Fatal("Ошибка подключения к БД", "error", err)
+	}
+
+	if migrate {
+		if err := runMigrate(pool, args[1:]); err != nil {
+			logging.Fatal("Ошибка миграции", "error", err)
+		}
+		pool.Close()
+		return
+	}
+	if cfg.AutoMigrate {
+		if err := db.MigrateUp(context.Background(), pool); err != nil {
+			logging.Fatal("Ошибка миграции", "error", err)
+		}
+	}
+
+	flushTraces, err := tracing.Setup(context.Background(), service.Version)
 	if err != nil {
-		log.Println("Не удалось загрузить .env, используется значение по умолчанию")
+		logging.Fatal("Ошибка настройки трассировки", "error", err)
 	}
-	dsn := os.Getenv("POSTGRES_DSN")
 
-	pool, err := db.NewPostgresDB(dsn)
+	app, err := service.NewApp(pool, cfg)
 	if err != nil {
-		log.Fatal("❌ Ошибка подключения к БД:", err)
+		logging.Fatal("Ошибка подключения к Tinkoff API", "error", err)
+	}
+
+	app.Jobs = scheduler.New()
+	if cfg.Jobs.Locking {
+		instance, _ := os.Hostname()
+		app.Jobs.Locker = app.Repo.NewJobLocker(fmt.Sprintf("%s:%d", instance, os.Getpid()))
+	}
+	if err := tasks.Register(app.Jobs, app, cfg.Jobs); err != nil {
+		logging.Fatal("Некорректное расписание задач", "error", err)
+	}
+	if cfg.Telegram.Token != "" {
+		bot := telegram.NewBot(app, telegram.Config{
+			Token:        cfg.Telegram.Token,
+			APIURL:       cfg.Telegram.APIURL,
+			AllowedChats: cfg.Telegram.ChatIDs,
+			DigestHour:   cfg.Telegram.DigestHour,
+		})
+		if err := bot.Register(app.Jobs); err != nil {
+			logging.Fatal("Некорректное расписание задач", "error", err)
+		}
 	}
 
-	app := service.NewApp(pool)
 	handler := handlers.NewHandler(app)
 	http.Handle("/swagger/", httpSwagger.WrapHandler)
 	http.HandleFunc("/summary", handler.SummaryHandler)
 	http.HandleFunc("/spravka", handler.SpravkaHandler)
 	http.HandleFunc("/figi/", handler.FigiHandler)
 	http.HandleFunc("/summary/save", handler.SaveSummaryHandler)
+	http.HandleFunc("/summary/snapshot", handler.SnapshotHandler)
 	http.HandleFunc("/summaries", handler.GetSummariesHandler)
+	http.HandleFunc("/summaries/retention", handler.RetentionHandler)
+	http.HandleFunc("/manual-operations", handler.ManualOperationsHandler)
+	http.HandleFunc("/manual-operations/", handler.ManualOperationHandler)
+	http.HandleFunc("/corporate-actions", handler.CorporateActionsHandler)
+	http.HandleFunc("/corporate-actions/", handler.CorporateActionHandler)
+	http.HandleFunc("/instrument-prices", handler.InstrumentPricesHandler)
+	http.HandleFunc("/instrument-prices/", handler.InstrumentPriceHandler)
+	http.HandleFunc("/security-transfers", handler.SecurityTransfersHandler)
+	http.HandleFunc("/security-transfers/", handler.SecurityTransferHandler)
+	http.HandleFunc("/lots", handler.LotsHandler)
+	http.HandleFunc("/equity", handler.EquityHandler)
+	http.HandleFunc("/equity/rebuild", handler.RebuildEquityHandler)
+	http.HandleFunc("/risk", handler.RiskHandler)
+	http.HandleFunc("/allocation", handler.AllocationHandler)
+	http.HandleFunc("/targets", handler.TargetsHandler)
+	http.HandleFunc("/rebalance", handler.RebalanceHandler)
+	http.HandleFunc("/tags", handler.TagsHandler)
+	http.HandleFunc("/tags/", handler.TagHandler)
+	http.HandleFunc("/instrument-tags", handler.InstrumentTagsHandler)
+	http.HandleFunc("/instrument-tags/", handler.InstrumentTagHandler)
+	http.HandleFunc("/operation-tags", handler.OperationTagsHandler)
+	http.HandleFunc("/operation-tags/", handler.OperationTagHandler)
+	http.HandleFunc("/alerts", handler.AlertsHandler)
+	http.HandleFunc("/alerts/", handler.AlertHandler)
+	http.HandleFunc("/alert-deliveries", handler.AlertDeliveriesHandler)
+	http.HandleFunc("/digests", handler.DigestsHandler)
+	http.HandleFunc("/digests/", handler.DigestHandler)
+	http.HandleFunc("/digest-preview", handler.DigestPreviewHandler)
+	http.HandleFunc("/jobs", handler.JobsHandler)
+	http.HandleFunc("/jobs/", handler.JobHandler)
+	http.HandleFunc("/job-runs", handler.JobRunsHandler)
+	http.HandleFunc("/healthz", handler.HealthzHandler)
+	http.HandleFunc("/readyz", handler.ReadyzHandler)
+	http.HandleFunc("/config", handler.ConfigHandler)
+	http.HandleFunc("/users", handler.UsersHandler)
+	http.HandleFunc("/users/", handler.UserHandler)
+	http.HandleFunc("/me", handler.MeHandler)
+	http.Handle("/metrics", metrics.Handler())
 
-	tasks.AutoSaveSummary(1 * time.Hour)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	app.Jobs.Start(ctx)
+	if cfg.Telegram.Token != "" {
+		// Опрос начинается сразу, а не с началом следующей минуты.
+		if err := app.Jobs.Trigger(telegram.PollJob); err != nil {
+			slog.Warn("Не удалось запустить Telegram-бота", "error", err)
+		}
+	}
 
-	log.Println("✅ Сервер запущен на :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	mux := http.DefaultServeMux
+	srv := &http.Server{
+		Addr:              cfg.ListenAddr,
+		Handler:           logging.RequestIDHandler(tracing.HTTPHandler(mux, metrics.InstrumentHandler(mux, handler.Authenticate(mux)))),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
+	go func() {
+		slog.Info("Сервер запущен", "addr", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Fatal("Ошибка сервера", "error", err)
+		}
+	}()
+
+	<-ctx.Done()
+	// Повторный сигнал завершает процесс сразу.
+	stop()
+	shutdown(srv, app, pool, flushTraces, cfg.HTTP.ShutdownTimeout)
+}
+
+// shutdown дожидается текущих запросов и фоновых задач не дольше timeout, затем закрывает
+// соединения с Tinkoff и БД и выгружает спаны. Контекст задач, в том числе Telegram-бота,
+// к этому моменту уже отменён.
+func shutdown(srv *http.Server, app *service.App, pool *pgxpool.Pool, flushTraces func(context.Context) error, timeout time.Duration) {
+	slog.Info("Остановка сервера", "timeout", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	slog.Info("Ожидание текущих запросов")
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("Не все запросы завершились", "error", err)
+	}
+
+	slog.Info("Ожидание фоновых задач")
+	clean := waitDone(ctx, app.Jobs.Wait)
+	slog.Info("Ожидание доставки оповещений")
+	clean = waitDone(ctx, app.WaitAlertDeliveries) && clean
+
+	slog.Info("Закрытие соединения с Tinkoff")
+	if err := app.Tinkoff.Close(); err != nil {
+		slog.Warn("Ошибка закрытия соединения с Tinkoff", "error", err)
+	}
+	// pool.Close ждёт возврата всех соединений, поэтому при зависшей задаче пропускается.
+	if clean {
+		slog.Info("Закрытие пула БД")
+		pool.Close()
+	} else {
+		slog.Warn("Фоновые задачи не завершились вовремя, пул БД не закрыт")
+	}
+	slog.Info("Выгрузка трасс")
+	// Отдельный таймаут: общий мог истечь на ожидании задач, а спаны о нём и нужны.
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := flushTraces(flushCtx); err != nil {
+		slog.Warn("Ошибка выгрузки трасс", "error", err)
+	}
+	slog.Info("Сервер остановлен")
+}
+
+// waitDone вызывает wait и ждёт его не дольше, чем до отмены ctx.
+func waitDone(ctx context.Context, wait func()) bool {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// runMigrate выполняет подкоманду: migrate [up | down [N] | status].
+func runMigrate(pool *pgxpool.Pool, args []string) error {
+	ctx := context.Background()
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	switch cmd {
+	case "up":
+		return db.MigrateUp(ctx, pool)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("некорректное число шагов отката: %s", args[1])
+			}
+			steps = n
+		}
+		return db.MigrateDown(ctx, pool, steps)
+	case "status":
+		statuses, err := db.GetMigrationStatus(ctx, pool)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "не применена"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return nil
+	default:
+		return fmt.Errorf("неизвестная команда migrate %s, ожидается up, down [N] или status", cmd)
+	}
 }
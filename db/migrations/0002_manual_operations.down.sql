@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS manual_operations_audit;
+DROP TABLE IF EXISTS manual_operations;
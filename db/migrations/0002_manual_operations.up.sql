@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS manual_operations (
+    id SERIAL PRIMARY KEY,
+    currency TEXT NOT NULL DEFAULT 'rub',
+    payment DOUBLE PRECISION NOT NULL DEFAULT 0,
+    operation_date TIMESTAMP NOT NULL,
+    type TEXT NOT NULL DEFAULT '',
+    operation_type TEXT NOT NULL,
+    figi TEXT NOT NULL DEFAULT '',
+    quantity DOUBLE PRECISION NOT NULL DEFAULT 0,
+    price DOUBLE PRECISION NOT NULL DEFAULT 0,
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS manual_operations_audit (
+    id SERIAL PRIMARY KEY,
+    operation_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    old_data JSONB,
+    new_data JSONB,
+    changed_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS manual_operations_audit_operation_id_idx
+    ON manual_operations_audit (operation_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS instrument_prices;
+DROP TABLE IF EXISTS corporate_actions;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS corporate_actions (
+    id SERIAL PRIMARY KEY,
+    action_type TEXT NOT NULL,
+    figi TEXT NOT NULL,
+    new_figi TEXT NOT NULL DEFAULT '',
+    ratio DOUBLE PRECISION NOT NULL DEFAULT 1,
+    effective_date TIMESTAMP NOT NULL,
+    source TEXT NOT NULL DEFAULT 'manual',
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS instrument_prices (
+    figi TEXT PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    last_price DOUBLE PRECISION,
+    last_price_at TIMESTAMP,
+    manual_price DOUBLE PRECISION,
+    manual_price_at TIMESTAMP
+);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS security_transfers (
+    operation_id TEXT PRIMARY KEY,
+    price DOUBLE PRECISION NOT NULL,
+    acquired_at TIMESTAMP,
+    comment TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP DEFAULT now()
+);
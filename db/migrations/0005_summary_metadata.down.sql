@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS summary_created_at_idx;
+
+ALTER TABLE summary
+    DROP COLUMN IF EXISTS account_id,
+    DROP COLUMN IF EXISTS period_from,
+    DROP COLUMN IF EXISTS period_to,
+    DROP COLUMN IF EXISTS currency,
+    DROP COLUMN IF EXISTS trigger,
+    DROP COLUMN IF EXISTS app_version,
+    DROP COLUMN IF EXISTS payload;
@@ -0,0 +1,10 @@
+ALTER TABLE summary
+    ADD COLUMN IF NOT EXISTS account_id TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS period_from TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS period_to TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'rub',
+    ADD COLUMN IF NOT EXISTS trigger TEXT NOT NULL DEFAULT 'manual',
+    ADD COLUMN IF NOT EXISTS app_version TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS payload JSONB;
+
+CREATE INDEX IF NOT EXISTS summary_created_at_idx ON summary (created_at);
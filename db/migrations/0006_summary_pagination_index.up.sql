@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS summary_created_at_idx;
+
+CREATE INDEX IF NOT EXISTS summary_created_at_id_idx ON summary (created_at, id);
+CREATE INDEX IF NOT EXISTS summary_account_created_at_idx ON summary (account_id, created_at, id);
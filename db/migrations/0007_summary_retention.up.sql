@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS summary_retention_runs (
+    id SERIAL PRIMARY KEY,
+    run_at TIMESTAMP NOT NULL DEFAULT now(),
+    trigger TEXT NOT NULL,
+    hourly_days INTEGER NOT NULL,
+    daily_months INTEGER NOT NULL,
+    dry_run BOOLEAN NOT NULL,
+    hourly_cutoff TIMESTAMP NOT NULL,
+    daily_cutoff TIMESTAMP NOT NULL,
+    pruned_count INTEGER NOT NULL,
+    pruned JSONB
+);
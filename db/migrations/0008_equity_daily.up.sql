@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS equity_daily (
+    account_id TEXT NOT NULL,
+    date DATE NOT NULL,
+    cash DOUBLE PRECISION NOT NULL,
+    market_value DOUBLE PRECISION NOT NULL,
+    equity DOUBLE PRECISION NOT NULL,
+    net_deposits DOUBLE PRECISION NOT NULL,
+    profit DOUBLE PRECISION NOT NULL,
+    twr_index DOUBLE PRECISION NOT NULL,
+    drawdown DOUBLE PRECISION NOT NULL,
+    computed_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (account_id, date)
+);
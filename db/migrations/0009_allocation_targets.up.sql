@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS allocation_targets (
+    dimension TEXT NOT NULL,
+    key TEXT NOT NULL,
+    weight DOUBLE PRECISION NOT NULL CHECK (weight > 0 AND weight <= 1),
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (dimension, key)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS operation_tags;
+DROP TABLE IF EXISTS instrument_tags;
+DROP TABLE IF EXISTS tags;
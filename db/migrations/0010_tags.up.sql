@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS tags (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS instrument_tags (
+    figi TEXT NOT NULL,
+    tag TEXT NOT NULL REFERENCES tags (name) ON DELETE CASCADE,
+    PRIMARY KEY (figi, tag)
+);
+
+CREATE TABLE IF NOT EXISTS operation_tags (
+    operation_id TEXT NOT NULL,
+    tag TEXT NOT NULL REFERENCES tags (name) ON DELETE CASCADE,
+    PRIMARY KEY (operation_id, tag)
+);
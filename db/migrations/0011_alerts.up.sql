@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id SERIAL PRIMARY KEY,
+    rule_type TEXT NOT NULL,
+    figi TEXT NOT NULL DEFAULT '',
+    direction TEXT NOT NULL DEFAULT '',
+    threshold DOUBLE PRECISION NOT NULL,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    comment TEXT NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT false,
+    last_value DOUBLE PRECISION,
+    last_triggered_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS alert_deliveries (
+    id SERIAL PRIMARY KEY,
+    rule_id INTEGER NOT NULL REFERENCES alert_rules (id) ON DELETE CASCADE,
+    webhook_url TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL,
+    response_code INTEGER,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS alert_deliveries_rule_idx ON alert_deliveries (rule_id, created_at DESC);
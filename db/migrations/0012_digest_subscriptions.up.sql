@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS digest_subscriptions (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL,
+    frequency TEXT NOT NULL,
+    send_hour INTEGER NOT NULL CHECK (send_hour BETWEEN 0 AND 23),
+    weekday INTEGER NOT NULL DEFAULT 1 CHECK (weekday BETWEEN 0 AND 6),
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    last_sent_at TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    last_attempt_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS job_runs (
+    id BIGSERIAL PRIMARY KEY,
+    job_name TEXT NOT NULL,
+    scheduled_at TIMESTAMP NOT NULL,
+    manual BOOLEAN NOT NULL DEFAULT false,
+    instance TEXT NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL DEFAULT now(),
+    finished_at TIMESTAMP,
+    UNIQUE (job_name, scheduled_at)
+);
+
+CREATE INDEX IF NOT EXISTS job_runs_started_idx ON job_runs (job_name, started_at DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE manual_operations_audit DROP COLUMN IF EXISTS changed_by;
+DROP TABLE IF EXISTS users;
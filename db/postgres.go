@@ -2,13 +2,88 @@ package db
 
 import (
 	"context"
+	"log/slog"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"tinvest_report/internal/metrics"
 )
 
 func NewPostgresDB(dsn string) (*pgxpool.Pool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	return pgxpool.New(ctx, dsn)
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ConnConfig.Tracer = queryTracer{}
+	return pgxpool.NewWithConfig(ctx, cfg)
+}
+
+var tracer = otel.Tracer("tinvest_report/db")
+
+// queryTracer замеряет длительность запросов для /metrics, создаёт спан на каждый запрос
+// и пишет запросы в лог с ID HTTP-запроса из контекста.
+type queryTracer struct{}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	at        time.Time
+	operation string
+}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	op := queryOperation(data.SQL)
+	ctx, _ = tracer.Start(ctx, "db "+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", op),
+			// Текст запроса без значений параметров: они передаются отдельно.
+			attribute.String("db.statement", data.SQL),
+		),
+	)
+	return context.WithValue(ctx, queryStartKey{}, queryStart{at: time.Now(), operation: op})
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(start.at)
+	metrics.DBQueryDuration.WithLabelValues(start.operation).Observe(elapsed.Seconds())
+
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		metrics.DBQueryErrors.WithLabelValues(start.operation).Inc()
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+		slog.WarnContext(ctx, "Ошибка запроса к БД", "operation", start.operation, "duration", elapsed, "error", data.Err)
+	} else {
+		slog.DebugContext(ctx, "Запрос к БД", "operation", start.operation, "duration", elapsed)
+	}
+	span.End()
+}
+
+// queryOperation возвращает тип запроса по первому слову: select, insert, update, delete, with или other.
+func queryOperation(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if i := strings.IndexFunc(sql, unicode.IsSpace); i >= 0 {
+		sql = sql[:i]
+	}
+	switch op := strings.ToLower(sql); op {
+	case "select", "insert", "update", "delete", "with":
+		return op
+	}
+	return "other"
 }
@@ -15,40 +15,124 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/figi/{figi}": {
+        "/alert-deliveries": {
             "get": {
-                "description": "Возвращает последнюю цену по указанному FIGI",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает последние попытки отправки оповещений по всем правилам",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tinkoff"
+                    "alerts"
                 ],
-                "summary": "Получение цены",
+                "summary": "Журнал доставки оповещений",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "FIGI инструмента",
-                        "name": "figi",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 50)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.PriceResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AlertDelivery"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/alerts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает все правила оповещений с их текущим состоянием",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Правила оповещений",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AlertRule"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "price_cross — цена figi выше (above) или ниже (below) threshold; daily_drop — стоимость портфеля\nза сутки упала на threshold процентов; net_profit_cross — чистая прибыль выше или ниже threshold.\nБез webhook_url используется адрес из ALERT_WEBHOOK_URL.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Добавление правила оповещения",
+                "parameters": [
+                    {
+                        "description": "Правило",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AlertRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.AlertRule"
                         }
                     },
                     "400": {
-                        "description": "FIGI не указан",
+                        "description": "Некорректное правило",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "Ошибка Tinkoff API",
+                        "description": "Ошибка при сохранении",
                         "schema": {
                             "type": "string"
                         }
@@ -56,28 +140,144 @@ const docTemplate = `{
                 }
             }
         },
-        "/spravka": {
+        "/alerts/{id}": {
             "get": {
-                "description": "Возвращает операции из Tinkoff Invest",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tinkoff"
+                    "alerts"
+                ],
+                "summary": "Правило оповещения",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID правила",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Операции",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Operation"
-                            }
+                            "$ref": "#/definitions/models.AlertRule"
+                        }
+                    },
+                    "404": {
+                        "description": "Правило не найдено",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "500": {
-                        "description": "Ошибка Tinkoff API",
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Обновляет правило и сбрасывает его состояние",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Изменение правила оповещения",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID правила",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Правило",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AlertRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AlertRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректное правило",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Правило не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет правило вместе с журналом его доставок",
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Удаление правила оповещения",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID правила",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Правило не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении",
                         "schema": {
                             "type": "string"
                         }
@@ -85,21 +285,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/summaries": {
+        "/alerts/{id}/deliveries": {
             "get": {
-                "description": "Возвращает все записи или за конкретную дату",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает журнал отправки оповещений правила, начиная с новых",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "summary"
+                    "alerts"
                 ],
-                "summary": "Получение отчётов",
+                "summary": "Доставки оповещений правила",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Дата в формате YYYY-MM-DD",
-                        "name": "date",
+                        "type": "integer",
+                        "description": "ID правила",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 50)",
+                        "name": "limit",
                         "in": "query"
                     }
                 ],
@@ -109,7 +321,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Summary"
+                                "$ref": "#/definitions/models.AlertDelivery"
                             }
                         }
                     },
@@ -122,21 +334,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/summary": {
+        "/allocation": {
             "get": {
-                "description": "Возвращает рассчитанный отчёт без сохранения",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Доли позиций по текущим ценам, сгруппированные по типу инструмента, сектору, валюте, стране риска и меткам. С tag учитываются только бумаги метки, без денег",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "summary"
+                    "portfolio"
+                ],
+                "summary": "Структура портфеля",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Метка стратегии; untagged — операции без меток",
+                        "name": "tag",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Генерация отчёта",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Summary"
+                            "$ref": "#/definitions/models.Allocation"
                         }
                     },
                     "500": {
@@ -148,9 +373,71 @@ const docTemplate = `{
                 }
             }
         },
-        "/summary/save": {
+        "/config": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает настройки после слияния файла, окружения и флагов. Токены и пароли не выводятся,\nвместо них — признак *_set; в DSN скрыт пароль, у счёта видны последние четыре символа",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Действующие настройки",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/config.Effective"
+                        }
+                    }
+                }
+            }
+        },
+        "/corporate-actions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает сплиты, конвертации и делистинги в порядке вступления в силу",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "corporate-actions"
+                ],
+                "summary": "Корпоративные действия",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CorporateAction"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Сохраняет отчёт, переданный в теле запроса",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Сохраняет сплит (split), конвертацию (conversion) или делистинг (delisting)",
                 "consumes": [
                     "application/json"
                 ],
@@ -158,29 +445,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "summary"
+                    "corporate-actions"
                 ],
-                "summary": "Сохранение отчёта",
+                "summary": "Добавление корпоративного действия",
                 "parameters": [
                     {
-                        "description": "Данные отчёта",
-                        "name": "summary",
+                        "description": "Корпоративное действие",
+                        "name": "action",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Summary"
+                            "$ref": "#/definitions/models.CorporateAction"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Summary saved successfully",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.CorporateAction"
                         }
                     },
                     "400": {
-                        "description": "Invalid JSON",
+                        "description": "Некорректное действие",
                         "schema": {
                             "type": "string"
                         }
@@ -193,31 +480,3369 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.Operation": {
-            "type": "object",
-            "properties": {
+        },
+        "/corporate-actions/detect": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Сравнивает позиции, восстановленные по операциям, с позициями у брокера и возвращает расхождения.\nНичего не сохраняет: если расхождение вызвано сплитом (suggested_type = split), его нужно добавить\nчерез POST /corporate-actions с датой вступления в силу; иначе проверьте ручные операции и переводы бумаг.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "corporate-actions"
+                ],
+                "summary": "Поиск корпоративных действий",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CorporateActionCandidate"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка Tinkoff API",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/corporate-actions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "corporate-actions"
+                ],
+                "summary": "Удаление корпоративного действия",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID действия",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Действие не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/digest-preview": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает HTML письма с отчётом без отправки",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "digests"
+                ],
+                "summary": "Предпросмотр письма",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "daily (по умолчанию) или weekly",
+                        "name": "frequency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "HTML письма",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный frequency",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/digests": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает подписки с расписанием и результатом последней отправки",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "digests"
+                ],
+                "summary": "Подписки на письма с отчётом",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DigestSubscription"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "frequency — daily или weekly; send_hour — час отправки по времени сервера; weekday — день недели для weekly (0 — воскресенье)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "digests"
+                ],
+                "summary": "Добавление подписки",
+                "parameters": [
+                    {
+                        "description": "Подписка",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DigestSubscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.DigestSubscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректная подписка",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/digests/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "digests"
+                ],
+                "summary": "Изменение подписки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Подписка",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DigestSubscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DigestSubscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректная подписка",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "digests"
+                ],
+                "summary": "Удаление подписки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/digests/{id}/send": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Отправляет письмо подписчику вне расписания, например для проверки настроек SMTP",
+                "tags": [
+                    "digests"
+                ],
+                "summary": "Отправка письма сейчас",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Ошибка отправки",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "SMTP не настроен",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/equity": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает дневной ряд капитала счёта: деньги, стоимость бумаг, чистые заводы, прибыль, индекс доходности без учёта заводов/выводов и просадку",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "equity"
+                ],
+                "summary": "Капитал по дням",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Начало периода, YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода включительно, YYYY-MM-DD",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.EquityPoint"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/equity/rebuild": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Восстанавливает ряд капитала от первой операции по журналу операций и дневным свечам.\nЕсли свечи не получены или для бумаги нет цены, сохранённый ряд не меняется.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "equity"
+                ],
+                "summary": "Пересчёт капитала",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.EquityPoint"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка пересчёта",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/figi/{figi}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает последнюю цену по указанному FIGI",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tinkoff"
+                ],
+                "summary": "Получение цены",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "FIGI инструмента",
+                        "name": "figi",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PriceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "FIGI не указан",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка Tinkoff API",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Отвечает 200, пока процесс работает; зависимости не проверяются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка живости",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/instrument-prices": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает назначенные вручную цены и последние полученные из API (обновляются при сохранении снимка отчёта и проверке оповещений)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "corporate-actions"
+                ],
+                "summary": "Известные цены инструментов",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/models.InstrumentPrice"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/instrument-prices/{figi}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Цена используется для делистингованных и замороженных бумаг, а также когда API не отдаёт цену",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "corporate-actions"
+                ],
+                "summary": "Назначение цены вручную",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "FIGI инструмента",
+                        "name": "figi",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Цена",
+                        "name": "price",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.manualPriceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Некорректная цена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "corporate-actions"
+                ],
+                "summary": "Сброс ручной цены",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "FIGI инструмента",
+                        "name": "figi",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/instrument-tags": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает метки, назначенные инструментам, по FIGI",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Метки инструментов",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/instrument-tags/{figi}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Заменяет метки инструмента. Метки инструмента применяются ко всем его операциям без собственных меток. Пустой массив снимает метки",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Назначение меток инструменту",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "FIGI инструмента",
+                        "name": "figi",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Метки",
+                        "name": "tags",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Некорректные метки",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/job-runs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает последние запуски всех задач на всех репликах",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "История запусков задач",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.JobRun"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает задачи планировщика: расписание, время и результат последнего запуска, время следующего",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Фоновые задачи",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/scheduler.JobStatus"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{name}/run": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Запускает задачу в фоне и сразу отвечает; результат виден в GET /jobs.\nЕсли задачу в это время выполняет другая реплика, запуск пропускается.",
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Запуск задачи вне расписания",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя задачи: autosave, retention, equity, alerts, digests",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Задача уже выполняется",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{name}/runs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает запуски задачи на всех репликах из таблицы job_runs, начиная с новых",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "История запусков задачи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя задачи",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.JobRun"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/lots": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает открытые лоты по FIFO с датами приобретения и признаком права на ЛДВ",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Открытые лоты",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Метка стратегии; untagged — операции без меток",
+                        "name": "tag",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Lot"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/manual-operations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает все ручные операции",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manual"
+                ],
+                "summary": "Ручные операции",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ManualOperation"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Сохраняет операцию, которой нет в API (OTC-перевод, подарок акций, перенос от другого брокера)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manual"
+                ],
+                "summary": "Добавление ручной операции",
+                "parameters": [
+                    {
+                        "description": "Операция",
+                        "name": "operation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ManualOperation"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ManualOperation"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректная операция",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/manual-operations/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает ручную операцию по ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manual"
+                ],
+                "summary": "Ручная операция",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID операции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ManualOperation"
+                        }
+                    },
+                    "404": {
+                        "description": "Операция не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Обновляет ручную операцию, предыдущее состояние сохраняется в журнале",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manual"
+                ],
+                "summary": "Изменение ручной операции",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID операции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Операция",
+                        "name": "operation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ManualOperation"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ManualOperation"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректная операция",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Операция не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет ручную операцию, её последнее состояние сохраняется в журнале",
+                "tags": [
+                    "manual"
+                ],
+                "summary": "Удаление ручной операции",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID операции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Операция не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/manual-operations/{id}/audit": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает историю создания, изменения и удаления операции с именем пользователя, выполнившего изменение",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manual"
+                ],
+                "summary": "Журнал изменений ручной операции",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID операции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ManualOperationAudit"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/me": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает пользователя, которому принадлежит ключ запроса, и его роль",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Текущий пользователь",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется ключ API",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/operation-tags": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает метки, назначенные отдельным операциям, по ID операции",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Метки операций",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/operation-tags/{operation_id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Заменяет метки операции; они имеют приоритет над метками инструмента. Пустой массив снимает метки",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Назначение меток операции",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID операции, для ручных — manual-N",
+                        "name": "operation_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Метки",
+                        "name": "tags",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Некорректные метки",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Проверяет Postgres, gRPC-соединение и токен Tinkoff (результат проверки токена кэшируется на минуту),\nвозвращает время последней загрузки операций и последнего автосохранения",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка готовности",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Readiness"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.Readiness"
+                        }
+                    }
+                }
+            }
+        },
+        "/rebalance": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Сделки в целых лотах, приводящие портфель к целевым долям измерения. Сначала продажи, затем покупки в пределах свободных денег и пополнения. Позиции без справочных данных в групповом режиме не продаются, если для unknown нет цели",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "portfolio"
+                ],
+                "summary": "Предложение ребалансировки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Измерение целевых долей, по умолчанию figi",
+                        "name": "by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Минимальная сумма сделки",
+                        "name": "min_trade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Сумма пополнения",
+                        "name": "deposit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только покупки, без продаж",
+                        "name": "deposit_only",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RebalancePlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Целевые доли не заданы",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/risk": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Годовая волатильность, максимальная просадка и её длительность, коэффициенты Шарпа и Сортино и бета к бенчмарку по ряду капитала за период",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "equity"
+                ],
+                "summary": "Риск-метрики",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Начало периода, YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода включительно, YYYY-MM-DD",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Годовая безрисковая ставка, 0.16 = 16%",
+                        "name": "risk_free_rate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "FIGI бенчмарка для беты",
+                        "name": "benchmark",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RiskMetrics"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Нет данных о капитале",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/security-transfers": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает операции ввода и вывода бумаг и указанную для них стоимость",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Переводы бумаг",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SecurityTransfer"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/security-transfers/{operation_id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Для ввода — цена и дата приобретения у предыдущего брокера, для вывода — оценка бумаг на дату вывода",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Стоимость перевода бумаг",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID операции ввода/вывода бумаг",
+                        "name": "operation_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Стоимость",
+                        "name": "basis",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SecurityTransferBasis"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Некорректная стоимость или операция не является переводом бумаг",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Операция не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Удаление стоимости перевода бумаг",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID операции ввода/вывода бумаг",
+                        "name": "operation_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Стоимость не указана",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/spravka": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает операции из Tinkoff Invest",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tinkoff"
+                ],
+                "summary": "Операции",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Operation"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка Tinkoff API",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/summaries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает сохранённые снимки с фильтром по периоду, пагинацией и агрегацией.\nКурсор следующей страницы возвращается в заголовке X-Next-Cursor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "summary"
+                ],
+                "summary": "Получение отчётов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Дата в формате YYYY-MM-DD (один день), нельзя вместе с from и to",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода, YYYY-MM-DD или RFC3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода, YYYY-MM-DD (день включительно) или RFC3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID счёта",
+                        "name": "account_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Последний снимок каждого счёта за период: day, week или month",
+                        "name": "aggregate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc или desc (по умолчанию desc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 500, не больше 5000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор из X-Next-Cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Summary"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Курсор следующей страницы"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/summaries/retention": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает последние прогоны прореживания снимков и список удалённых записей",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "summary"
+                ],
+                "summary": "История очистки снимков",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Количество прогонов (по умолчанию 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RetentionRun"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Прореживает снимки по настроенной политике хранения. По умолчанию dry run — только отчёт о том, что будет удалено.\nЗадача retention удаляет снимки только после того, как политика с теми же параметрами хотя бы раз запущена здесь.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "summary"
+                ],
+                "summary": "Очистка снимков",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "false — удалить снимки (по умолчанию true)",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RetentionRun"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка очистки",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/summary": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает рассчитанный отчёт без сохранения. Учитывает ручные операции и корпоративные действия.\nС tag отчёт считается только по операциям с меткой, с group_by=tag возвращается массив отчётов по каждой метке. Пополнения и выводы относятся ко всему счёту и в отчёты по меткам не входят.\nБумаги, введённые без стоимости приобретения, не учитываются, пока она не указана через PUT /security-transfers/{id};\nтакой отчёт помечен incomplete, операции перечислены в missing_cost_basis.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "summary"
+                ],
+                "summary": "Генерация отчёта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Метка стратегии; untagged — операции без меток",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "tag — отдельный отчёт по каждой метке",
+                        "name": "group_by",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.Summary"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/summary/save": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Сохраняет отчёт, переданный в теле запроса. Только для роли admin.\nПоля проверяются на согласованность: turnover = total_buys + total_sells,\nnet_stock_profit = total_sells + portfolio_value - total_buys - commissions - taxes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "summary"
+                ],
+                "summary": "Сохранение отчёта (admin)",
+                "parameters": [
+                    {
+                        "description": "Данные отчёта",
+                        "name": "summary",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Summary"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Summary saved successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Несогласованные поля отчёта",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/summary/snapshot": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Рассчитывает отчёт на сервере по текущим операциям и ценам и сохраняет его",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "summary"
+                ],
+                "summary": "Снимок отчёта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Источник снимка: manual (по умолчанию) или auto",
+                        "name": "trigger",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Summary"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный trigger",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает все метки",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Метки стратегий",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Tag"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создаёт метку или обновляет её описание",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Создание метки",
+                "parameters": [
+                    {
+                        "description": "Метка",
+                        "name": "tag",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Tag"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tag"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректная метка",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags/{name}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет метку вместе с её назначениями инструментам и операциям",
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Удаление метки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя метки",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Метка не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/targets": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает целевые доли инструментов или групп; без dimension — все измерения",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "portfolio"
+                ],
+                "summary": "Целевые доли",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "figi, type, sector, currency или country",
+                        "name": "dimension",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AllocationTarget"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неизвестное измерение",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Заменяет все целевые доли измерения. Доля — от 0 до 1, сумма не больше 1, остаток — деньги",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "portfolio"
+                ],
+                "summary": "Задание целевых долей",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "figi, type, sector, currency или country",
+                        "name": "dimension",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Целевые доли",
+                        "name": "targets",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.allocationTargetRequest"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Некорректные доли",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает пользователей с ролями и временем последнего запроса. Только для admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Пользователи API",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создаёт пользователя с ролью viewer или admin и выпускает ключ API. Ключ возвращается\nтолько в этом ответе, в БД хранится его хэш. Только для admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Создание пользователя",
+                "parameters": [
+                    {
+                        "description": "Имя и роль",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.userRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Пользователь уже существует",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Меняет роль пользователя; поле name игнорируется. Последнего администратора понизить нельзя. Только для admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Смена роли пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новая роль",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.userRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректная роль",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Последний администратор",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет пользователя вместе с ключом. Последнего администратора удалить нельзя. Только для admin",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Удаление пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Последний администратор",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/key": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выпускает пользователю новый ключ API, старый сразу перестаёт действовать. Только для admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Перевыпуск ключа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserKey"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "config.Effective": {
+            "type": "object",
+            "properties": {
+                "admin_token_set": {
+                    "type": "boolean"
+                },
+                "alerts": {
+                    "$ref": "#/definitions/config.EffectiveAlerts"
+                },
+                "auto_migrate": {
+                    "type": "boolean"
+                },
+                "config_file": {
+                    "type": "string"
+                },
+                "http": {
+                    "$ref": "#/definitions/config.EffectiveHTTP"
+                },
+                "jobs": {
+                    "$ref": "#/definitions/config.Jobs"
+                },
+                "listen_addr": {
+                    "type": "string"
+                },
+                "log": {
+                    "$ref": "#/definitions/config.Log"
+                },
+                "postgres_dsn": {
+                    "type": "string"
+                },
+                "report_currency": {
+                    "type": "string"
+                },
+                "retention": {
+                    "$ref": "#/definitions/config.EffectiveRetention"
+                },
+                "risk": {
+                    "$ref": "#/definitions/config.EffectiveRisk"
+                },
+                "smtp": {
+                    "$ref": "#/definitions/config.EffectiveSMTP"
+                },
+                "telegram": {
+                    "$ref": "#/definitions/config.EffectiveTelegram"
+                },
+                "tinkoff": {
+                    "$ref": "#/definitions/config.EffectiveTinkoff"
+                }
+            }
+        },
+        "config.EffectiveAlerts": {
+            "type": "object",
+            "properties": {
+                "max_attempts": {
+                    "type": "integer"
+                },
+                "price_max_age": {
+                    "type": "string"
+                },
+                "retry_delay": {
+                    "type": "string"
+                },
+                "webhook_url_set": {
+                    "description": "Адрес webhook часто содержит ключ доступа, поэтому выводится только признак.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "config.EffectiveHTTP": {
+            "type": "object",
+            "properties": {
+                "idle_timeout": {
+                    "type": "string"
+                },
+                "read_timeout": {
+                    "type": "string"
+                },
+                "shutdown_timeout": {
+                    "type": "string"
+                },
+                "write_timeout": {
+                    "type": "string"
+                }
+            }
+        },
+        "config.EffectiveRetention": {
+            "type": "object",
+            "properties": {
+                "daily_months": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "hourly_days": {
+                    "type": "integer"
+                }
+            }
+        },
+        "config.EffectiveRisk": {
+            "type": "object",
+            "properties": {
+                "benchmark_figi": {
+                    "type": "string"
+                },
+                "risk_free_rate": {
+                    "type": "number"
+                }
+            }
+        },
+        "config.EffectiveSMTP": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "host": {
+                    "type": "string"
+                },
+                "password_set": {
+                    "type": "boolean"
+                },
+                "port": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "config.EffectiveTelegram": {
+            "type": "object",
+            "properties": {
+                "api_url": {
+                    "type": "string"
+                },
+                "chat_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "digest_hour": {
+                    "type": "integer"
+                },
+                "enabled": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "config.EffectiveTinkoff": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "account_index": {
+                    "type": "integer"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "token_set": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "config.Jobs": {
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "type": "string"
+                },
+                "autosave": {
+                    "type": "string"
+                },
+                "digests": {
+                    "type": "string"
+                },
+                "equity": {
+                    "type": "string"
+                },
+                "locking": {
+                    "description": "Locking — запуск каждой задачи только на одной реплике через блокировку в Postgres.",
+                    "type": "boolean"
+                },
+                "retention": {
+                    "type": "string"
+                }
+            }
+        },
+        "config.Log": {
+            "type": "object",
+            "properties": {
+                "format": {
+                    "type": "string"
+                },
+                "level": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.allocationTargetRequest": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "number"
+                }
+            }
+        },
+        "handlers.manualPriceRequest": {
+            "type": "object",
+            "properties": {
+                "price": {
+                    "type": "number"
+                }
+            }
+        },
+        "handlers.userRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AlertDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "response_code": {
+                    "type": "integer"
+                },
+                "rule_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "webhook_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AlertRule": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "direction": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "figi": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_triggered_at": {
+                    "type": "string"
+                },
+                "last_value": {
+                    "type": "number"
+                },
+                "rule_type": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "webhook_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Allocation": {
+            "type": "object",
+            "properties": {
+                "by_country": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AllocationSlice"
+                    }
+                },
+                "by_currency": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AllocationSlice"
+                    }
+                },
+                "by_sector": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AllocationSlice"
+                    }
+                },
+                "by_tag": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AllocationSlice"
+                    }
+                },
+                "by_type": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AllocationSlice"
+                    }
+                },
+                "cash": {
+                    "type": "number"
+                },
+                "excluded": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AllocationPosition"
+                    }
+                },
+                "incomplete": {
+                    "type": "boolean"
+                },
+                "positions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AllocationPosition"
+                    }
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.AllocationPosition": {
+            "type": "object",
+            "properties": {
+                "country": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "figi": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "sector": {
+                    "type": "string"
+                },
+                "ticker": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                },
+                "weight": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.AllocationSlice": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                },
+                "weight": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.AllocationTarget": {
+            "type": "object",
+            "properties": {
+                "dimension": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CorporateAction": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "effective_date": {
+                    "type": "string"
+                },
+                "figi": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "new_figi": {
+                    "type": "string"
+                },
+                "ratio": {
+                    "type": "number"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CorporateActionCandidate": {
+            "type": "object",
+            "properties": {
+                "actual_quantity": {
+                    "type": "number"
+                },
+                "expected_quantity": {
+                    "type": "number"
+                },
+                "figi": {
+                    "type": "string"
+                },
+                "ratio": {
+                    "type": "number"
+                },
+                "suggested_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DependencyStatus": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DigestSubscription": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "failed_attempts": {
+                    "description": "FailedAttempts — неудачные попытки отправки по расписанию подряд, LastAttemptAt — время последней.",
+                    "type": "integer"
+                },
+                "frequency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_attempt_at": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_sent_at": {
+                    "type": "string"
+                },
+                "send_hour": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "weekday": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.EquityPoint": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "cash": {
+                    "type": "number"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "drawdown": {
+                    "type": "number"
+                },
+                "equity": {
+                    "type": "number"
+                },
+                "market_value": {
+                    "type": "number"
+                },
+                "net_deposits": {
+                    "type": "number"
+                },
+                "profit": {
+                    "type": "number"
+                },
+                "twr_index": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.InstrumentPrice": {
+            "type": "object",
+            "properties": {
+                "figi": {
+                    "type": "string"
+                },
+                "last_price": {
+                    "type": "number"
+                },
+                "last_price_at": {
+                    "type": "string"
+                },
+                "manual_price": {
+                    "type": "number"
+                },
+                "manual_price_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.InstrumentSummary": {
+            "type": "object",
+            "properties": {
+                "figi": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.JobRun": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "job_name": {
+                    "type": "string"
+                },
+                "manual": {
+                    "type": "boolean"
+                },
+                "scheduled_at": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Lot": {
+            "type": "object",
+            "properties": {
+                "acquired_at": {
+                    "type": "string"
+                },
+                "figi": {
+                    "type": "string"
+                },
+                "ldv_eligible": {
+                    "type": "boolean"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.ManualOperation": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "figi": {
+                    "type": "string"
+                },
+                "float_payment": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "operation_type": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ManualOperationAudit": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "changed_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "new_data": {
+                    "type": "object"
+                },
+                "old_data": {
+                    "type": "object"
+                },
+                "operation_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Operation": {
+            "type": "object",
+            "properties": {
                 "currency": {
                     "type": "string"
                 },
-                "date": {
+                "date": {
+                    "type": "string"
+                },
+                "figi": {
+                    "type": "string"
+                },
+                "float_payment": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_canceled": {
+                    "type": "boolean"
+                },
+                "is_manual": {
+                    "type": "boolean"
+                },
+                "operation_type": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PriceResponse": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.PrunedSnapshot": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "tier": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Readiness": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string"
+                },
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.DependencyStatus"
+                    }
+                },
+                "last_autosave": {
                     "type": "string"
                 },
-                "figi": {
+                "last_sync": {
                     "type": "string"
                 },
-                "float_payment": {
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RebalancePlan": {
+            "type": "object",
+            "properties": {
+                "cash_after": {
                     "type": "number"
                 },
-                "id": {
+                "cash_before": {
+                    "type": "number"
+                },
+                "dimension": {
                     "type": "string"
                 },
-                "is_canceled": {
-                    "type": "boolean"
+                "excluded": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "operation_type": {
+                "total": {
+                    "type": "number"
+                },
+                "trades": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RebalanceTrade"
+                    }
+                },
+                "unallocated": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.RebalanceTrade": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "current_weight": {
+                    "type": "number"
+                },
+                "figi": {
+                    "type": "string"
+                },
+                "lots": {
+                    "type": "integer"
+                },
+                "name": {
                     "type": "string"
                 },
                 "price": {
@@ -226,40 +3851,334 @@ const docTemplate = `{
                 "quantity": {
                     "type": "number"
                 },
-                "type": {
+                "target_weight": {
+                    "type": "number"
+                },
+                "ticker": {
                     "type": "string"
+                },
+                "value": {
+                    "type": "number"
                 }
             }
         },
-        "models.PriceResponse": {
+        "models.RetentionRun": {
             "type": "object",
             "properties": {
-                "name": {
+                "daily_cutoff": {
+                    "type": "string"
+                },
+                "daily_months": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "hourly_cutoff": {
+                    "type": "string"
+                },
+                "hourly_days": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "pruned": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PrunedSnapshot"
+                    }
+                },
+                "pruned_count": {
+                    "type": "integer"
+                },
+                "run_at": {
+                    "type": "string"
+                },
+                "trigger": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RiskMetrics": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "annualized_return": {
+                    "type": "number"
+                },
+                "benchmark_figi": {
+                    "type": "string"
+                },
+                "beta": {
+                    "type": "number"
+                },
+                "days": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "max_drawdown": {
+                    "type": "number"
+                },
+                "max_drawdown_days": {
+                    "type": "integer"
+                },
+                "risk_free_rate": {
+                    "type": "number"
+                },
+                "sharpe": {
+                    "type": "number"
+                },
+                "sortino": {
+                    "type": "number"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total_return": {
+                    "type": "number"
+                },
+                "volatility": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.SecurityTransfer": {
+            "type": "object",
+            "properties": {
+                "basis": {
+                    "$ref": "#/definitions/models.SecurityTransferBasis"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "direction": {
+                    "type": "string"
+                },
+                "figi": {
+                    "type": "string"
+                },
+                "operation_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.SecurityTransferBasis": {
+            "type": "object",
+            "properties": {
+                "acquired_at": {
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "operation_id": {
                     "type": "string"
                 },
                 "price": {
                     "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
         "models.Summary": {
             "type": "object",
             "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "app_version": {
+                    "type": "string"
+                },
                 "commissions": {
                     "type": "number"
                 },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "instruments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.InstrumentSummary"
+                    }
+                },
+                "net_stock_profit": {
+                    "type": "number"
+                },
+                "period_from": {
+                    "type": "string"
+                },
+                "period_to": {
+                    "type": "string"
+                },
+                "portfolio_value": {
+                    "type": "number"
+                },
+                "taxes": {
+                    "type": "number"
+                },
+                "total_buys": {
+                    "type": "number"
+                },
+                "total_input": {
+                    "type": "number"
+                },
+                "total_output": {
+                    "type": "number"
+                },
+                "total_sells": {
+                    "type": "number"
+                },
+                "trigger": {
+                    "type": "string"
+                },
+                "turnover": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.Tag": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
                 "created_at": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
+                "key_prefix": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UserKey": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "scheduler.JobStatus": {
+            "type": "object",
+            "properties": {
+                "last_duration": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_start": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_run": {
+                    "type": "string"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "runs": {
+                    "type": "integer"
+                },
+                "schedule": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "description": "Skipped — сколько слотов выполнила другая реплика.",
+                    "type": "integer"
+                }
+            }
+        },
+        "service.Summary": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "app_version": {
+                    "type": "string"
+                },
+                "commissions": {
+                    "type": "number"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "incomplete": {
+                    "description": "Incomplete — есть введённые бумаги без стоимости приобретения (их операции в MissingCostBasis):\nпока стоимость не указана, они не учитываются ни в стоимости портфеля, ни в прибыли.",
+                    "type": "boolean"
+                },
+                "instruments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.InstrumentSummary"
+                    }
+                },
+                "missing_cost_basis": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "net_stock_profit": {
                     "type": "number"
                 },
+                "period_from": {
+                    "type": "string"
+                },
+                "period_to": {
+                    "type": "string"
+                },
                 "portfolio_value": {
                     "type": "number"
                 },
+                "tag": {
+                    "type": "string"
+                },
                 "taxes": {
                     "type": "number"
                 },
@@ -280,6 +4199,14 @@ const docTemplate = `{
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "Ключ API в виде \"Bearer \u003cключ\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
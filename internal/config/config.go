@@ -0,0 +1,371 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"tinvest_report/internal/mailer"
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/scheduler"
+)
+
+// DefaultFile — файл настроек, который читается, если не задан -config или CONFIG_FILE.
+const DefaultFile = ".env"
+
+// Config — настройки приложения. Значения берутся по возрастанию приоритета: значения
+// по умолчанию, файл в формате .env, переменные окружения, флаги командной строки.
+type Config struct {
+	// File — прочитанный файл настроек; пусто, если файла нет.
+	File string
+
+	ListenAddr  string
+	PostgresDSN string
+	AutoMigrate bool
+	// ReportCurrency — валюта отчёта, пока поддерживается только rub.
+	ReportCurrency string
+	// AdminToken — ключ администратора, действующий без записи в users; нужен, чтобы создать первого пользователя.
+	AdminToken string
+
+	Log       Log
+	HTTP      HTTP
+	Tinkoff   Tinkoff
+	Jobs      Jobs
+	Retention models.RetentionPolicy
+	Risk      models.RiskConfig
+	Alerts    models.AlertConfig
+	// SMTP.Host пуст, если письма не настроены.
+	SMTP mailer.Config
+	// Telegram.Token пуст, если бот выключен.
+	Telegram Telegram
+	// Warnings — предупреждения об устаревших настройках; выводятся в лог после его настройки.
+	Warnings []string
+}
+
+type Log struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+}
+
+type HTTP struct {
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+}
+
+type Tinkoff struct {
+	Token    string
+	Endpoint string
+	// AccountID — счёт для отчётов; если пуст, берётся счёт с номером AccountIndex из списка счетов.
+	AccountID    string
+	AccountIndex int
+}
+
+// Jobs — расписания фоновых задач в формате cron (см. scheduler.Parse).
+type Jobs struct {
+	// Locking — запуск каждой задачи только на одной реплике через блокировку в Postgres.
+	Locking   bool   `json:"locking"`
+	Autosave  string `json:"autosave"`
+	Retention string `json:"retention"`
+	Equity    string `json:"equity"`
+	Alerts    string `json:"alerts"`
+	Digests   string `json:"digests"`
+}
+
+type Telegram struct {
+	Token   string
+	APIURL  string
+	ChatIDs []int64
+	// DigestHour — час отправки ежедневного дайджеста; -1 — не отправлять.
+	DigestHour int
+}
+
+// Load читает настройки из файла, окружения и флагов args (без имени программы) и возвращает
+// оставшиеся после флагов аргументы, например подкоманду migrate. Ошибки разбора значений
+// возвращаются все сразу; проверку согласованности делает Validate.
+func Load(args []string) (*Config, []string, error) {
+	fs := flag.NewFlagSet("tinvest_report", flag.ContinueOnError)
+	file := fs.String("config", "", "файл настроек в формате .env (по умолчанию CONFIG_FILE или .env)")
+	listen := fs.String("listen", "", "адрес HTTP-сервера, LISTEN_ADDR")
+	dsn := fs.String("dsn", "", "строка подключения к Postgres, POSTGRES_DSN")
+	account := fs.String("account", "", "ID счёта Tinkoff, TINKOFF_ACCOUNT_ID")
+	endpoint := fs.String("tinkoff-endpoint", "", "адрес Tinkoff Invest API, TINKOFF_ENDPOINT")
+	logLevel := fs.String("log-level", "", "уровень логов, LOG_LEVEL")
+	logFormat := fs.String("log-format", "", "формат логов, LOG_FORMAT")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	cfg := &Config{}
+	if err := cfg.loadFile(*file); err != nil {
+		return nil, nil, err
+	}
+
+	var l loader
+	cfg.ListenAddr = l.string("LISTEN_ADDR", ":8080")
+	cfg.PostgresDSN = l.string("POSTGRES_DSN", "")
+	cfg.AutoMigrate = l.bool("AUTO_MIGRATE", true)
+	cfg.ReportCurrency = strings.ToLower(l.string("REPORT_CURRENCY", "rub"))
+	cfg.AdminToken = l.string("ADMIN_TOKEN", "")
+	cfg.Log = Log{
+		Level:  l.string("LOG_LEVEL", "info"),
+		Format: l.string("LOG_FORMAT", "text"),
+	}
+	cfg.HTTP = HTTP{
+		ReadTimeout: l.seconds("HTTP_READ_TIMEOUT_SECONDS", 30),
+		// Пересчёт капитала и отчёт с большим числом операций считаются минутами.
+		WriteTimeout:    l.seconds("HTTP_WRITE_TIMEOUT_SECONDS", 300),
+		IdleTimeout:     l.seconds("HTTP_IDLE_TIMEOUT_SECONDS", 120),
+		ShutdownTimeout: l.seconds("SHUTDOWN_TIMEOUT_SECONDS", 30),
+	}
+	cfg.Tinkoff = Tinkoff{
+		Token:    l.string("TINKOFF_TOKEN", ""),
+		Endpoint: l.string("TINKOFF_ENDPOINT", "invest-public-api.tinkoff.ru:443"),
+		// Раньше отчёт всегда строился по второму счёту из списка; по умолчанию так и остаётся.
+		AccountID:    l.string("TINKOFF_ACCOUNT_ID", ""),
+		AccountIndex: l.int("TINKOFF_ACCOUNT_INDEX", 1),
+	}
+	// ALERT_INTERVAL_MINUTES задавала и период проверки оповещений, и допустимый возраст цены;
+	// её заменили JOB_ALERTS_SCHEDULE и ALERT_PRICE_MAX_AGE_MINUTES, но старое значение
+	// по-прежнему действует, если новые не заданы.
+	alertsSchedule, priceMaxAge := "*/5 * * * *", 5
+	if minutes := l.legacyInt("ALERT_INTERVAL_MINUTES", "JOB_ALERTS_SCHEDULE", "ALERT_PRICE_MAX_AGE_MINUTES"); minutes > 0 {
+		alertsSchedule, priceMaxAge = fmt.Sprintf("@every %dm", minutes), minutes
+	}
+	// Снимок раз в час, очистка и пересчёт капитала раз в сутки ночью, оповещения и письма каждые 5 минут.
+	cfg.Jobs = Jobs{
+		Locking:   l.bool("JOB_LOCKING", true),
+		Autosave:  l.string("JOB_AUTOSAVE_SCHEDULE", "0 * * * *"),
+		Retention: l.string("JOB_RETENTION_SCHEDULE", "30 3 * * *"),
+		Equity:    l.string("JOB_EQUITY_SCHEDULE", "0 4 * * *"),
+		Alerts:    l.string("JOB_ALERTS_SCHEDULE", alertsSchedule),
+		Digests:   l.string("JOB_DIGESTS_SCHEDULE", "*/5 * * * *"),
+	}
+	cfg.Retention = models.RetentionPolicy{
+		HourlyDays:  l.int("RETENTION_HOURLY_DAYS", 30),
+		DailyMonths: l.int("RETENTION_DAILY_MONTHS", 12),
+		DryRun:      l.bool("RETENTION_DRY_RUN", true),
+	}
+	cfg.Risk = models.RiskConfig{
+		RiskFreeRate:  l.float("RISK_FREE_RATE", 0),
+		BenchmarkFIGI: l.string("RISK_BENCHMARK_FIGI", ""),
+	}
+	cfg.Alerts = models.AlertConfig{
+		WebhookURL:  l.string("ALERT_WEBHOOK_URL", ""),
+		MaxAttempts: l.int("ALERT_MAX_ATTEMPTS", 3),
+		RetryDelay:  l.seconds("ALERT_RETRY_SECONDS", 5),
+		PriceMaxAge: time.Duration(l.int("ALERT_PRICE_MAX_AGE_MINUTES", priceMaxAge)) * time.Minute,
+	}
+	cfg.SMTP = mailer.Config{
+		Host:     l.string("SMTP_HOST", ""),
+		Port:     l.int("SMTP_PORT", 587),
+		Username: l.string("SMTP_USERNAME", ""),
+		Password: l.string("SMTP_PASSWORD", ""),
+		From:     l.string("SMTP_FROM", ""),
+	}
+	cfg.Telegram = Telegram{
+		Token:      l.string("TELEGRAM_BOT_TOKEN", ""),
+		APIURL:     l.string("TELEGRAM_API_URL", ""),
+		ChatIDs:    l.chatIDs("TELEGRAM_CHAT_IDS"),
+		DigestHour: l.int("TELEGRAM_DIGEST_HOUR", 9),
+	}
+
+	// Флаги перекрывают файл и окружение, только если заданы явно.
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "listen":
+			cfg.ListenAddr = *listen
+		case "dsn":
+			cfg.PostgresDSN = *dsn
+		case "account":
+			cfg.Tinkoff.AccountID = *account
+		case "tinkoff-endpoint":
+			cfg.Tinkoff.Endpoint = *endpoint
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		case "log-format":
+			cfg.Log.Format = *logFormat
+		}
+	})
+	cfg.Warnings = l.warnings
+	return cfg, fs.Args(), errors.Join(l.errs...)
+}
+
+// loadFile добавляет в окружение значения из файла, не перекрывая уже заданные переменные.
+// Отсутствие файла по умолчанию не ошибка: в контейнере настройки приходят через окружение.
+func (c *Config) loadFile(path string) error {
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv("CONFIG_FILE")
+		explicit = path != ""
+	}
+	if !explicit {
+		path = DefaultFile
+	}
+	if _, err := os.Stat(path); err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("файл настроек %s: %w", path, err)
+	}
+	if err := godotenv.Load(path); err != nil {
+		return fmt.Errorf("файл настроек %s: %w", path, err)
+	}
+	c.File = path
+	return nil
+}
+
+// Validate проверяет, что настроек достаточно для запуска сервера, и возвращает все ошибки сразу.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(validAddr(c.ListenAddr), "LISTEN_ADDR", "ожидается host:port, получено %q", c.ListenAddr)
+	check(c.PostgresDSN != "", "POSTGRES_DSN", "не задан")
+	check(c.ReportCurrency == "rub", "REPORT_CURRENCY", "поддерживается только rub, пересчёт отчёта в %s не реализован", c.ReportCurrency)
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "LOG_LEVEL", "ожидается debug, info, warn или error, получено %q", c.Log.Level)
+	format := strings.ToLower(c.Log.Format)
+	check(format == "text" || format == "json", "LOG_FORMAT", "ожидается text или json, получено %q", c.Log.Format)
+
+	check(c.HTTP.ReadTimeout > 0, "HTTP_READ_TIMEOUT_SECONDS", "должно быть больше нуля")
+	check(c.HTTP.WriteTimeout > 0, "HTTP_WRITE_TIMEOUT_SECONDS", "должно быть больше нуля")
+	check(c.HTTP.IdleTimeout > 0, "HTTP_IDLE_TIMEOUT_SECONDS", "должно быть больше нуля")
+	check(c.HTTP.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT_SECONDS", "должно быть больше нуля")
+
+	check(c.Tinkoff.Token != "", "TINKOFF_TOKEN", "не задан")
+	check(validAddr(c.Tinkoff.Endpoint), "TINKOFF_ENDPOINT", "ожидается host:port, получено %q", c.Tinkoff.Endpoint)
+	check(c.Tinkoff.AccountIndex >= 0, "TINKOFF_ACCOUNT_INDEX", "не может быть отрицательным")
+
+	for _, j := range []struct{ key, spec string }{
+		{"JOB_AUTOSAVE_SCHEDULE", c.Jobs.Autosave},
+		{"JOB_RETENTION_SCHEDULE", c.Jobs.Retention},
+		{"JOB_EQUITY_SCHEDULE", c.Jobs.Equity},
+		{"JOB_ALERTS_SCHEDULE", c.Jobs.Alerts},
+		{"JOB_DIGESTS_SCHEDULE", c.Jobs.Digests},
+	} {
+		_, err := scheduler.Parse(j.spec)
+		check(err == nil, j.key, "%v", err)
+	}
+
+	check(c.Retention.HourlyDays >= 0, "RETENTION_HOURLY_DAYS", "не может быть отрицательным")
+	check(c.Retention.DailyMonths >= 0, "RETENTION_DAILY_MONTHS", "не может быть отрицательным")
+	check(c.Alerts.MaxAttempts >= 1, "ALERT_MAX_ATTEMPTS", "должно быть не меньше 1")
+	check(c.Alerts.PriceMaxAge > 0, "ALERT_PRICE_MAX_AGE_MINUTES", "должно быть больше нуля")
+
+	if c.SMTP.Host != "" {
+		check(c.SMTP.Port > 0 && c.SMTP.Port < 65536, "SMTP_PORT", "ожидается порт от 1 до 65535, получено %d", c.SMTP.Port)
+		check(c.SMTP.From != "", "SMTP_FROM", "обязателен при заданном SMTP_HOST")
+	}
+	if c.Telegram.Token != "" {
+		check(len(c.Telegram.ChatIDs) > 0, "TELEGRAM_CHAT_IDS", "обязателен при заданном TELEGRAM_BOT_TOKEN, иначе бот никому не ответит")
+		check(c.Telegram.DigestHour >= -1 && c.Telegram.DigestHour <= 23, "TELEGRAM_DIGEST_HOUR", "ожидается час от 0 до 23 или -1")
+	}
+	return errors.Join(errs...)
+}
+
+func validAddr(addr string) bool {
+	_, port, err := net.SplitHostPort(addr)
+	return err == nil && port != ""
+}
+
+// loader читает переменные окружения и копит ошибки разбора, чтобы показать их все сразу.
+type loader struct {
+	errs     []error
+	warnings []string
+}
+
+func (l *loader) fail(key, value, expected string) {
+	l.errs = append(l.errs, fmt.Errorf("%s: ожидается %s, получено %q", key, expected, value))
+}
+
+func (l *loader) string(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
+
+func (l *loader) int(key string, def int) int {
+	v := l.string(key, "")
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		l.fail(key, v, "целое число")
+		return def
+	}
+	return n
+}
+
+// legacyInt читает устаревшую переменную key и, если она задана, запоминает предупреждение
+// о переменных replacements, которые её заменили. Возвращает 0, если переменной нет.
+func (l *loader) legacyInt(key string, replacements ...string) int {
+	if l.string(key, "") == "" {
+		return 0
+	}
+	l.warnings = append(l.warnings, fmt.Sprintf("%s устарела, используйте %s", key, strings.Join(replacements, " и ")))
+	return l.int(key, 0)
+}
+
+func (l *loader) float(key string, def float64) float64 {
+	v := l.string(key, "")
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		l.fail(key, v, "число")
+		return def
+	}
+	return f
+}
+
+func (l *loader) bool(key string, def bool) bool {
+	v := l.string(key, "")
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.fail(key, v, "true или false")
+		return def
+	}
+	return b
+}
+
+func (l *loader) seconds(key string, def int) time.Duration {
+	return time.Duration(l.int(key, def)) * time.Second
+}
+
+// chatIDs разбирает список ID чатов через запятую.
+func (l *loader) chatIDs(key string) []int64 {
+	var ids []int64
+	for _, part := range strings.Split(l.string(key, ""), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			l.fail(key, part, "ID чата")
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
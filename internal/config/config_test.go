@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLegacyAlertInterval(t *testing.T) {
+	t.Setenv("ALERT_INTERVAL_MINUTES", "15")
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Jobs.Alerts != "@every 15m" || cfg.Alerts.PriceMaxAge != 15*time.Minute {
+		t.Errorf("alerts schedule = %q, price max age = %v", cfg.Jobs.Alerts, cfg.Alerts.PriceMaxAge)
+	}
+	if len(cfg.Warnings) != 1 {
+		t.Errorf("warnings = %v, want one about ALERT_INTERVAL_MINUTES", cfg.Warnings)
+	}
+
+	// Новые переменные важнее устаревшей.
+	t.Setenv("JOB_ALERTS_SCHEDULE", "*/2 * * * *")
+	t.Setenv("ALERT_PRICE_MAX_AGE_MINUTES", "3")
+	cfg, _, err = Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Jobs.Alerts != "*/2 * * * *" || cfg.Alerts.PriceMaxAge != 3*time.Minute {
+		t.Errorf("alerts schedule = %q, price max age = %v", cfg.Jobs.Alerts, cfg.Alerts.PriceMaxAge)
+	}
+}
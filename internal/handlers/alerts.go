@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+)
+
+func (h *Handler) AlertsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listAlertRules(w, r)
+	case http.MethodPost:
+		h.createAlertRule(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) AlertHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/alerts/")
+	idStr, sub, _ := strings.Cut(path, "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Некорректный ID правила", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case sub == "deliveries" && r.Method == http.MethodGet:
+		h.getAlertDeliveries(w, r, id)
+	case sub != "":
+		http.NotFound(w, r)
+	case r.Method == http.MethodGet:
+		h.getAlertRule(w, r, id)
+	case r.Method == http.MethodPut:
+		h.updateAlertRule(w, r, id)
+	case r.Method == http.MethodDelete:
+		h.deleteAlertRule(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// @Summary Правила оповещений
+// @Description Возвращает все правила оповещений с их текущим состоянием
+// @Tags alerts
+// @Produce json
+// @Success 200 {array} models.AlertRule
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /alerts [get]
+
+func (h *Handler) listAlertRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.app.Repo.ListAlertRules(r.Context(), false)
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rules == nil {
+		rules = []models.AlertRule{}
+	}
+	for i := range rules {
+		rules[i].WebhookURL = visibleWebhook(r, rules[i].WebhookURL)
+	}
+	writeJSON(w, http.StatusOK, rules)
+}
+
+// @Summary Добавление правила оповещения
+// @Description price_cross — цена figi выше (above) или ниже (below) threshold; daily_drop — стоимость портфеля
+// @Description за сутки упала на threshold процентов; net_profit_cross — чистая прибыль выше или ниже threshold.
+// @Description Без webhook_url используется адрес из ALERT_WEBHOOK_URL.
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param rule body models.AlertRule true "Правило"
+// @Success 201 {object} models.AlertRule
+// @Failure 400 {string} string "Некорректное правило"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /alerts [post]
+
+func (h *Handler) createAlertRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := decodeAlertRule(w, r)
+	if !ok {
+		return
+	}
+	created, err := h.app.Repo.CreateAlertRule(r.Context(), rule)
+	if err != nil {
+		http.Error(w, "Ошибка при сохранении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// @Summary Правило оповещения
+// @Tags alerts
+// @Produce json
+// @Param id path int true "ID правила"
+// @Success 200 {object} models.AlertRule
+// @Failure 404 {string} string "Правило не найдено"
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /alerts/{id} [get]
+
+func (h *Handler) getAlertRule(w http.ResponseWriter, r *http.Request, id int) {
+	rule, err := h.app.Repo.GetAlertRule(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Правило не найдено", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rule.WebhookURL = visibleWebhook(r, rule.WebhookURL)
+	writeJSON(w, http.StatusOK, rule)
+}
+
+// @Summary Изменение правила оповещения
+// @Description Обновляет правило и сбрасывает его состояние
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path int true "ID правила"
+// @Param rule body models.AlertRule true "Правило"
+// @Success 200 {object} models.AlertRule
+// @Failure 400 {string} string "Некорректное правило"
+// @Failure 404 {string} string "Правило не найдено"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /alerts/{id} [put]
+
+func (h *Handler) updateAlertRule(w http.ResponseWriter, r *http.Request, id int) {
+	rule, ok := decodeAlertRule(w, r)
+	if !ok {
+		return
+	}
+	updated, err := h.app.Repo.UpdateAlertRule(r.Context(), id, rule)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Правило не найдено", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка при сохранении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// @Summary Удаление правила оповещения
+// @Description Удаляет правило вместе с журналом его доставок
+// @Tags alerts
+// @Param id path int true "ID правила"
+// @Success 204
+// @Failure 404 {string} string "Правило не найдено"
+// @Failure 500 {string} string "Ошибка при удалении"
+// @Security ApiKeyAuth
+// @Router /alerts/{id} [delete]
+
+func (h *Handler) deleteAlertRule(w http.ResponseWriter, r *http.Request, id int) {
+	err := h.app.Repo.DeleteAlertRule(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Правило не найдено", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка при удалении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Доставки оповещений правила
+// @Description Возвращает журнал отправки оповещений правила, начиная с новых
+// @Tags alerts
+// @Produce json
+// @Param id path int true "ID правила"
+// @Param limit query int false "Количество записей (по умолчанию 50)"
+// @Success 200 {array} models.AlertDelivery
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /alerts/{id}/deliveries [get]
+
+func (h *Handler) getAlertDeliveries(w http.ResponseWriter, r *http.Request, ruleID int) {
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Некорректный limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.app.Repo.GetAlertDeliveries(r.Context(), ruleID, limit)
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for i := range deliveries {
+		deliveries[i].WebhookURL = visibleWebhook(r, deliveries[i].WebhookURL)
+	}
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// @Summary Журнал доставки оповещений
+// @Description Возвращает последние попытки отправки оповещений по всем правилам
+// @Tags alerts
+// @Produce json
+// @Param limit query int false "Количество записей (по умолчанию 50)"
+// @Success 200 {array} models.AlertDelivery
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /alert-deliveries [get]
+
+func (h *Handler) AlertDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.getAlertDeliveries(w, r, 0)
+}
+
+func decodeAlertRule(w http.ResponseWriter, r *http.Request) (models.AlertRule, bool) {
+	rule := models.AlertRule{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return rule, false
+	}
+
+	switch rule.Type {
+	case models.AlertPriceCross:
+		if rule.FIGI == "" {
+			http.Error(w, "Для price_cross нужен figi", http.StatusBadRequest)
+			return rule, false
+		}
+		if rule.Threshold <= 0 {
+			http.Error(w, "Порог цены должен быть положительным", http.StatusBadRequest)
+			return rule, false
+		}
+	case models.AlertDailyDrop:
+		if rule.Threshold <= 0 || rule.Threshold >= 100 {
+			http.Error(w, "Порог падения задаётся в процентах от 0 до 100", http.StatusBadRequest)
+			return rule, false
+		}
+		rule.FIGI, rule.Direction = "", ""
+	case models.AlertNetProfitCross:
+		rule.FIGI = ""
+	default:
+		http.Error(w, "rule_type должен быть price_cross, daily_drop или net_profit_cross", http.StatusBadRequest)
+		return rule, false
+	}
+
+	if rule.Type != models.AlertDailyDrop {
+		switch rule.Direction {
+		case "":
+			rule.Direction = models.AlertAbove
+		case models.AlertAbove, models.AlertBelow:
+		default:
+			http.Error(w, "direction должен быть above или below", http.StatusBadRequest)
+			return rule, false
+		}
+	}
+
+	if rule.WebhookURL != "" {
+		u, err := url.Parse(rule.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			http.Error(w, "Некорректный webhook_url", http.StatusBadRequest)
+			return rule, false
+		}
+	}
+	return rule, true
+}
+
+// visibleWebhook оставляет адрес webhook целиком только администратору. Остальным, как и в /config,
+// он не показывается: путь и параметры адреса часто содержат ключ доступа, поэтому видны только
+// схема и хост.
+func visibleWebhook(r *http.Request, webhookURL string) string {
+	if u, ok := currentUser(r.Context()); (ok && u.Role == models.RoleAdmin) || webhookURL == "" {
+		return webhookURL
+	}
+	u, err := url.Parse(webhookURL)
+	if err != nil || u.Host == "" {
+		return "[REDACTED]"
+	}
+	return u.Scheme + "://" + u.Host + "/[REDACTED]"
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"tinvest_report/internal/models"
+)
+
+func TestVisibleWebhook(t *testing.T) {
+	const hook = "https://hooks.example.com/services/T000/B000/secret?token=abc"
+	asUser := func(role string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/alerts", nil)
+		return r.WithContext(context.WithValue(r.Context(), userKey{}, models.User{Name: role, Role: role}))
+	}
+
+	if got := visibleWebhook(asUser(models.RoleAdmin), hook); got != hook {
+		t.Errorf("admin sees %q, want the full address", got)
+	}
+	if got := visibleWebhook(asUser(models.RoleViewer), hook); got != "https://hooks.example.com/[REDACTED]" {
+		t.Errorf("viewer sees %q", got)
+	}
+	if got := visibleWebhook(httptest.NewRequest(http.MethodGet, "/alerts", nil), hook); got == hook {
+		t.Error("request without a user sees the full address")
+	}
+	if got := visibleWebhook(asUser(models.RoleViewer), ""); got != "" {
+		t.Errorf("empty address became %q", got)
+	}
+}
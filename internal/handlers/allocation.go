@@ -0,0 +1,22 @@
+package handlers
+
+import "net/http"
+
+// @Summary Структура портфеля
+// @Description Доли позиций по текущим ценам, сгруппированные по типу инструмента, сектору, валюте, стране риска и меткам. С tag учитываются только бумаги метки, без денег
+// @Tags portfolio
+// @Produce json
+// @Param tag query string false "Метка стратегии; untagged — операции без меток"
+// @Success 200 {object} models.Allocation
+// @Failure 500 {string} string "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /allocation [get]
+
+func (h *Handler) AllocationHandler(w http.ResponseWriter, r *http.Request) {
+	alloc, err := h.app.GetAllocation(r.Context(), r.URL.Query().Get("tag"))
+	if err != nil {
+		http.Error(w, "Ошибка расчёта структуры портфеля: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, alloc)
+}
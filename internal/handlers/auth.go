@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/service"
+)
+
+// publicRoutes доступны без ключа: пробы оркестратора и документация API.
+var publicRoutes = map[string]bool{
+	"/healthz":  true,
+	"/readyz":   true,
+	"/swagger/": true,
+}
+
+// adminRoutes требуют роли admin для любого метода, в том числе чтения.
+var adminRoutes = map[string]bool{
+	"/config": true,
+	"/users":  true,
+	"/users/": true,
+}
+
+type userKey struct{}
+
+// currentUser возвращает пользователя, от имени которого выполняется запрос.
+func currentUser(ctx context.Context) (models.User, bool) {
+	u, ok := ctx.Value(userKey{}).(models.User)
+	return u, ok
+}
+
+// Authenticate проверяет ключ API из заголовка Authorization: Bearer <ключ> или X-API-Key.
+// Чтение (GET, HEAD) доступно ролям viewer и admin, изменение и маршруты adminRoutes — только admin.
+// Маршрут определяется по шаблону mux, как в метриках.
+func (h *Handler) Authenticate(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		if publicRoutes[route] {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		key := requestAPIKey(r)
+		if key == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="tinvest_report"`)
+			http.Error(w, "Требуется ключ API", http.StatusUnauthorized)
+			return
+		}
+		user, err := h.app.Authenticate(r.Context(), key)
+		if errors.Is(err, service.ErrUnauthorized) {
+			slog.WarnContext(r.Context(), "Неизвестный ключ API", "path", r.URL.Path)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="tinvest_report", error="invalid_token"`)
+			http.Error(w, "Неизвестный ключ API", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Ошибка проверки ключа: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		readOnly := r.Method == http.MethodGet || r.Method == http.MethodHead
+		if user.Role != models.RoleAdmin && (!readOnly || adminRoutes[route]) {
+			slog.WarnContext(r.Context(), "Недостаточно прав", "user", user.Name, "role", user.Role, "method", r.Method, "path", r.URL.Path)
+			http.Error(w, "Недостаточно прав", http.StatusForbidden)
+			return
+		}
+		if !readOnly {
+			slog.InfoContext(r.Context(), "Изменяющий запрос", "user", user.Name, "method", r.Method, "path", r.URL.Path)
+		}
+		mux.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, user)))
+	})
+}
+
+// userName возвращает имя пользователя запроса для журналов изменений.
+func userName(r *http.Request) string {
+	u, _ := currentUser(r.Context())
+	return u.Name
+}
+
+func requestAPIKey(r *http.Request) string {
+	if scheme, key, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(key)
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"tinvest_report/internal/logging"
+)
+
+// @Summary Действующие настройки
+// @Description Возвращает настройки после слияния файла, окружения и флагов. Токены и пароли не выводятся,
+// @Description вместо них — признак *_set; в DSN скрыт пароль, у счёта видны последние четыре символа
+// @Tags config
+// @Produce json
+// @Success 200 {object} config.Effective
+// @Security ApiKeyAuth
+// @Router /config [get]
+
+func (h *Handler) ConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	e := h.app.Config.Effective()
+	// Счёт, выбранный при запуске, в том числе по TINKOFF_ACCOUNT_INDEX.
+	e.Tinkoff.AccountID = logging.MaskAccountID(h.app.Tinkoff.AccountID())
+	writeJSON(w, http.StatusOK, e)
+}
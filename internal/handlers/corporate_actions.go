@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"tinvest_report/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (h *Handler) CorporateActionsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listCorporateActions(w, r)
+	case http.MethodPost:
+		h.createCorporateAction(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) CorporateActionHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/corporate-actions/")
+	if path == "detect" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.detectCorporateActions(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(path)
+	if err != nil {
+		http.Error(w, "Некорректный ID действия", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.deleteCorporateAction(w, r, id)
+}
+
+// @Summary Корпоративные действия
+// @Description Возвращает сплиты, конвертации и делистинги в порядке вступления в силу
+// @Tags corporate-actions
+// @Produce json
+// @Success 200 {array} models.CorporateAction
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /corporate-actions [get]
+
+func (h *Handler) listCorporateActions(w http.ResponseWriter, r *http.Request) {
+	actions, err := h.app.Repo.ListCorporateActions(r.Context())
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, actions)
+}
+
+// @Summary Добавление корпоративного действия
+// @Description Сохраняет сплит (split), конвертацию (conversion) или делистинг (delisting)
+// @Tags corporate-actions
+// @Accept json
+// @Produce json
+// @Param action body models.CorporateAction true "Корпоративное действие"
+// @Success 201 {object} models.CorporateAction
+// @Failure 400 {string} string "Некорректное действие"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /corporate-actions [post]
+
+func (h *Handler) createCorporateAction(w http.ResponseWriter, r *http.Request) {
+	var action models.CorporateAction
+	if err := json.NewDecoder(r.Body).Decode(&action); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if msg := validateCorporateAction(&action); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	action.Source = models.CorporateActionSourceManual
+
+	created, err := h.app.Repo.CreateCorporateAction(r.Context(), action)
+	if err != nil {
+		http.Error(w, "Ошибка при сохранении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// @Summary Удаление корпоративного действия
+// @Tags corporate-actions
+// @Param id path int true "ID действия"
+// @Success 204
+// @Failure 404 {string} string "Действие не найдено"
+// @Failure 500 {string} string "Ошибка при удалении"
+// @Security ApiKeyAuth
+// @Router /corporate-actions/{id} [delete]
+
+func (h *Handler) deleteCorporateAction(w http.ResponseWriter, r *http.Request, id int) {
+	err := h.app.Repo.DeleteCorporateAction(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Действие не найдено", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка при удалении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Поиск корпоративных действий
+// @Description Сравнивает позиции, восстановленные по операциям, с позициями у брокера и возвращает расхождения.
+// @Description Ничего не сохраняет: если расхождение вызвано сплитом (suggested_type = split), его нужно добавить
+// @Description через POST /corporate-actions с датой вступления в силу; иначе проверьте ручные операции и переводы бумаг.
+// @Tags corporate-actions
+// @Produce json
+// @Success 200 {array} models.CorporateActionCandidate
+// @Failure 500 {string} string "Ошибка Tinkoff API"
+// @Security ApiKeyAuth
+// @Router /corporate-actions/detect [get]
+
+func (h *Handler) detectCorporateActions(w http.ResponseWriter, r *http.Request) {
+	detected, err := h.app.DetectCorporateActions(r.Context())
+	if err != nil {
+		http.Error(w, "Ошибка поиска корпоративных действий: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, detected)
+}
+
+func validateCorporateAction(a *models.CorporateAction) string {
+	if a.FIGI == "" {
+		return "FIGI не указан"
+	}
+	if a.EffectiveDate.IsZero() {
+		return "Не указана дата вступления в силу"
+	}
+	switch a.Type {
+	case models.CorporateActionSplit:
+		if a.Ratio <= 0 {
+			return "ratio должен быть больше нуля"
+		}
+	case models.CorporateActionConversion:
+		if a.Ratio <= 0 {
+			return "ratio должен быть больше нуля"
+		}
+		if a.NewFIGI == "" {
+			return "Для конвертации нужен new_figi"
+		}
+	case models.CorporateActionDelisting:
+		a.Ratio = 1
+	default:
+		return "type должен быть split, conversion или delisting"
+	}
+	return ""
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/service"
+)
+
+func (h *Handler) DigestsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listDigestSubscriptions(w, r)
+	case http.MethodPost:
+		h.createDigestSubscription(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) DigestHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/digests/")
+	idStr, sub, _ := strings.Cut(path, "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Некорректный ID подписки", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case sub == "send" && r.Method == http.MethodPost:
+		h.sendDigestNow(w, r, id)
+	case sub != "":
+		http.NotFound(w, r)
+	case r.Method == http.MethodPut:
+		h.updateDigestSubscription(w, r, id)
+	case r.Method == http.MethodDelete:
+		h.deleteDigestSubscription(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// @Summary Подписки на письма с отчётом
+// @Description Возвращает подписки с расписанием и результатом последней отправки
+// @Tags digests
+// @Produce json
+// @Success 200 {array} models.DigestSubscription
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /digests [get]
+
+func (h *Handler) listDigestSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.app.Repo.ListDigestSubscriptions(r.Context())
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if subs == nil {
+		subs = []models.DigestSubscription{}
+	}
+	writeJSON(w, http.StatusOK, subs)
+}
+
+// @Summary Добавление подписки
+// @Description frequency — daily или weekly; send_hour — час отправки по времени сервера; weekday — день недели для weekly (0 — воскресенье)
+// @Tags digests
+// @Accept json
+// @Produce json
+// @Param subscription body models.DigestSubscription true "Подписка"
+// @Success 201 {object} models.DigestSubscription
+// @Failure 400 {string} string "Некорректная подписка"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /digests [post]
+
+func (h *Handler) createDigestSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, ok := decodeDigestSubscription(w, r)
+	if !ok {
+		return
+	}
+	created, err := h.app.Repo.CreateDigestSubscription(r.Context(), sub)
+	if err != nil {
+		http.Error(w, "Ошибка при сохранении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// @Summary Изменение подписки
+// @Tags digests
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param subscription body models.DigestSubscription true "Подписка"
+// @Success 200 {object} models.DigestSubscription
+// @Failure 400 {string} string "Некорректная подписка"
+// @Failure 404 {string} string "Подписка не найдена"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /digests/{id} [put]
+
+func (h *Handler) updateDigestSubscription(w http.ResponseWriter, r *http.Request, id int) {
+	sub, ok := decodeDigestSubscription(w, r)
+	if !ok {
+		return
+	}
+	updated, err := h.app.Repo.UpdateDigestSubscription(r.Context(), id, sub)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Подписка не найдена", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка при сохранении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// @Summary Удаление подписки
+// @Tags digests
+// @Param id path int true "ID подписки"
+// @Success 204
+// @Failure 404 {string} string "Подписка не найдена"
+// @Failure 500 {string} string "Ошибка при удалении"
+// @Security ApiKeyAuth
+// @Router /digests/{id} [delete]
+
+func (h *Handler) deleteDigestSubscription(w http.ResponseWriter, r *http.Request, id int) {
+	err := h.app.Repo.DeleteDigestSubscription(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Подписка не найдена", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка при удалении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Отправка письма сейчас
+// @Description Отправляет письмо подписчику вне расписания, например для проверки настроек SMTP
+// @Tags digests
+// @Param id path int true "ID подписки"
+// @Success 204
+// @Failure 404 {string} string "Подписка не найдена"
+// @Failure 502 {string} string "Ошибка отправки"
+// @Failure 503 {string} string "SMTP не настроен"
+// @Security ApiKeyAuth
+// @Router /digests/{id}/send [post]
+
+func (h *Handler) sendDigestNow(w http.ResponseWriter, r *http.Request, id int) {
+	sub, err := h.app.Repo.GetDigestSubscription(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Подписка не найдена", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = h.app.SendDigest(r.Context(), sub, time.Now())
+	if errors.Is(err, service.ErrMailerDisabled) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка отправки: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Предпросмотр письма
+// @Description Возвращает HTML письма с отчётом без отправки
+// @Tags digests
+// @Produce html
+// @Param frequency query string false "daily (по умолчанию) или weekly"
+// @Success 200 {string} string "HTML письма"
+// @Failure 400 {string} string "Некорректный frequency"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /digest-preview [get]
+
+func (h *Handler) DigestPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	frequency := r.URL.Query().Get("frequency")
+	switch frequency {
+	case "":
+		frequency = models.DigestDaily
+	case models.DigestDaily, models.DigestWeekly:
+	default:
+		http.Error(w, "frequency должен быть daily или weekly", http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.app.BuildDigest(r.Context(), frequency, time.Now())
+	if err != nil {
+		http.Error(w, "Ошибка расчёта отчёта: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	html, err := service.RenderDigest(d)
+	if err != nil {
+		http.Error(w, "Ошибка формирования письма: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(html))
+}
+
+func decodeDigestSubscription(w http.ResponseWriter, r *http.Request) (models.DigestSubscription, bool) {
+	sub := models.DigestSubscription{Enabled: true, Weekday: int(time.Monday)}
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return sub, false
+	}
+	addr, err := mail.ParseAddress(sub.Email)
+	if err != nil {
+		http.Error(w, "Некорректный email", http.StatusBadRequest)
+		return sub, false
+	}
+	sub.Email = addr.Address
+	if sub.Frequency != models.DigestDaily && sub.Frequency != models.DigestWeekly {
+		http.Error(w, "frequency должен быть daily или weekly", http.StatusBadRequest)
+		return sub, false
+	}
+	if sub.SendHour < 0 || sub.SendHour > 23 {
+		http.Error(w, "send_hour должен быть от 0 до 23", http.StatusBadRequest)
+		return sub, false
+	}
+	if sub.Weekday < 0 || sub.Weekday > 6 {
+		http.Error(w, "weekday должен быть от 0 (воскресенье) до 6", http.StatusBadRequest)
+		return sub, false
+	}
+	return sub, true
+}
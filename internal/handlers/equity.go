@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// @Summary Капитал по дням
+// @Description Возвращает дневной ряд капитала счёта: деньги, стоимость бумаг, чистые заводы, прибыль, индекс доходности без учёта заводов/выводов и просадку
+// @Tags equity
+// @Produce json
+// @Param from query string false "Начало периода, YYYY-MM-DD"
+// @Param to query string false "Конец периода включительно, YYYY-MM-DD"
+// @Success 200 {array} models.EquityPoint
+// @Failure 400 {string} string "Некорректные параметры"
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /equity [get]
+
+func (h *Handler) EquityHandler(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parsePeriod(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	points, err := h.app.Repo.GetEquity(r.Context(), h.app.Tinkoff.AccountID(), from, to)
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, points)
+}
+
+// @Summary Пересчёт капитала
+// @Description Восстанавливает ряд капитала от первой операции по журналу операций и дневным свечам.
+// @Description Если свечи не получены или для бумаги нет цены, сохранённый ряд не меняется.
+// @Tags equity
+// @Produce json
+// @Success 200 {array} models.EquityPoint
+// @Failure 500 {string} string "Ошибка пересчёта"
+// @Security ApiKeyAuth
+// @Router /equity/rebuild [post]
+
+func (h *Handler) RebuildEquityHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	points, err := h.app.RebuildEquity(r.Context())
+	if err != nil {
+		http.Error(w, "Ошибка пересчёта капитала: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, points)
+}
+
+// parsePeriod читает from/to в формате YYYY-MM-DD; отсутствующая граница остаётся нулевой.
+func parsePeriod(r *http.Request) (from, to time.Time, err error) {
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			return from, to, fmt.Errorf("некорректный from: %s, ожидается YYYY-MM-DD", v)
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			return from, to, fmt.Errorf("некорректный to: %s, ожидается YYYY-MM-DD", v)
+		}
+	}
+	return from, to, nil
+}
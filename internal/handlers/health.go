@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"tinvest_report/internal/models"
+)
+
+// @Summary Проверка живости
+// @Description Отвечает 200, пока процесс работает; зависимости не проверяются
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+
+func (h *Handler) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": models.HealthOK})
+}
+
+// @Summary Проверка готовности
+// @Description Проверяет Postgres, gRPC-соединение и токен Tinkoff (результат проверки токена кэшируется на минуту),
+// @Description возвращает время последней загрузки операций и последнего автосохранения
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.Readiness
+// @Failure 503 {object} models.Readiness
+// @Router /readyz [get]
+
+func (h *Handler) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	ready := h.app.Readiness(r.Context())
+	status := http.StatusOK
+	if ready.Status != models.HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, ready)
+}
@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
 	"math"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 	"tinvest_report/internal/models"
+	"tinvest_report/internal/repository"
 
 	"tinvest_report/internal/service"
 )
@@ -25,10 +29,11 @@ func NewHandler(app *service.App) *Handler {
 // @Produce json
 // @Success 200 {array} models.Operation
 // @Failure 500 {string} string "Ошибка Tinkoff API"
+// @Security ApiKeyAuth
 // @Router /spravka [get]
 
 func (h *Handler) SpravkaHandler(w http.ResponseWriter, r *http.Request) {
-	ops, err := h.app.Tinkoff.GetOperations()
+	ops, err := h.app.Tinkoff.GetOperations(r.Context())
 	if err != nil {
 		http.Error(w, "Ошибка получения операций: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -44,6 +49,7 @@ func (h *Handler) SpravkaHandler(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} models.PriceResponse
 // @Failure 400 {string} string "FIGI не указан"
 // @Failure 500 {string} string "Ошибка Tinkoff API"
+// @Security ApiKeyAuth
 // @Router /figi/{figi} [get]
 
 func (h *Handler) FigiHandler(w http.ResponseWriter, r *http.Request) {
@@ -52,7 +58,7 @@ func (h *Handler) FigiHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "FIGI не указан", http.StatusBadRequest)
 		return
 	}
-	priceData, err := h.app.Tinkoff.GetFigiPrice(figi)
+	priceData, err := h.app.Tinkoff.GetFigiPrice(r.Context(), figi)
 	if err != nil {
 		http.Error(w, "Ошибка получения цены: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -60,112 +66,98 @@ func (h *Handler) FigiHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(priceData)
 }
 
-type Summary struct {
-	TotalInput     float64 `json:"total_input"`
-	TotalOutput    float64 `json:"total_output"`
-	Turnover       float64 `json:"turnover"`
-	TotalBuys      float64 `json:"total_buys"`
-	TotalSells     float64 `json:"total_sells"`
-	PortfolioValue float64 `json:"portfolio_value"`
-	Commissions    float64 `json:"commissions"`
-	Taxes          float64 `json:"taxes"`
-	NetStockProfit float64 `json:"net_stock_profit"`
-}
-
 // @Summary Генерация отчёта
-// @Description Возвращает рассчитанный отчёт без сохранения
+// @Description Возвращает рассчитанный отчёт без сохранения. Учитывает ручные операции и корпоративные действия.
+// @Description С tag отчёт считается только по операциям с меткой, с group_by=tag возвращается массив отчётов по каждой метке. Пополнения и выводы относятся ко всему счёту и в отчёты по меткам не входят.
+// @Description Бумаги, введённые без стоимости приобретения, не учитываются, пока она не указана через PUT /security-transfers/{id};
+// @Description такой отчёт помечен incomplete, операции перечислены в missing_cost_basis.
 // @Tags summary
 // @Produce json
-// @Success 200 {object} models.Summary
+// @Param tag query string false "Метка стратегии; untagged — операции без меток"
+// @Param group_by query string false "tag — отдельный отчёт по каждой метке"
+// @Success 200 {object} service.Summary
+// @Failure 400 {string} string "Некорректные параметры"
 // @Failure 500 {string} string "Ошибка сервера"
+// @Security ApiKeyAuth
 // @Router /summary [get]
 
 func (h *Handler) SummaryHandler(w http.ResponseWriter, r *http.Request) {
-	ops, err := h.app.Tinkoff.GetOperations()
-	if err != nil {
-		http.Error(w, "Ошибка загрузки операций: "+err.Error(), http.StatusInternalServerError)
+	q := r.URL.Query()
+	switch q.Get("group_by") {
+	case "":
+	case "tag":
+		summaries, err := h.app.BuildSummariesByTag(r.Context())
+		if err != nil {
+			http.Error(w, "Ошибка расчёта отчёта: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, summaries)
+		return
+	default:
+		http.Error(w, "group_by поддерживает только tag", http.StatusBadRequest)
 		return
 	}
 
-	var totalInput, totalOutput, turnover, totalBuys, totalSells, commissions, taxes float64
-	figiHoldings := make(map[string]float64)
-
-	log.Println("🔍 Подробности по операциям:")
-	for _, op := range ops {
-		if op.IsCanceled {
-			log.Printf("[CANCELED] %s | %s | %.2f ₽", op.Date, op.Operation, op.FloatPayment)
-			continue
-		}
-
-		switch op.Operation {
-		case "OPERATION_TYPE_INPUT", "OPERATION_TYPE_INP_MULTI":
-			totalInput += op.FloatPayment
-		case "OPERATION_TYPE_OUTPUT", "OPERATION_TYPE_OUT_MULTI":
-			totalOutput += -op.FloatPayment
-		case "OPERATION_TYPE_BUY":
-			if !strings.HasPrefix(op.Figi, "FUT") {
-				totalBuys += -op.FloatPayment
-				turnover += -op.FloatPayment
-				figiHoldings[op.Figi] += op.Quantity
-			}
-		case "OPERATION_TYPE_SELL":
-			if !strings.HasPrefix(op.Figi, "FUT") {
-				totalSells += op.FloatPayment
-				turnover += op.FloatPayment
-				figiHoldings[op.Figi] -= op.Quantity
-			}
-		case "OPERATION_TYPE_BROKER_FEE", "OPERATION_TYPE_TRACK_MFEE", "OPERATION_TYPE_TRACK_PFEE":
-			commissions += -op.FloatPayment
-		case "OPERATION_TYPE_TAX":
-			taxes += -op.FloatPayment
-		}
+	summary, err := h.app.BuildSummary(r.Context(), q.Get("tag"))
+	if err != nil {
+		http.Error(w, "Ошибка расчёта отчёта: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	var portfolioValue float64
-	for figi, qty := range figiHoldings {
-		if figi == "" || math.Abs(qty) < 0.0001 {
-			continue
-		}
-		if math.Abs(qty) < 0.0001 {
-			continue
-		}
-		priceData, err := h.app.Tinkoff.GetFigiPrice(figi)
-		if err != nil {
-			log.Printf("❌ Не удалось получить цену для %s: %v", figi, err)
-			continue
-		}
-		portfolioValue += qty * priceData.Price
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		http.Error(w, "Ошибка кодирования", http.StatusInternalServerError)
 	}
+}
 
-	netProfit := (totalSells + portfolioValue) - totalBuys - commissions - taxes
+// @Summary Снимок отчёта
+// @Description Рассчитывает отчёт на сервере по текущим операциям и ценам и сохраняет его
+// @Tags summary
+// @Produce json
+// @Param trigger query string false "Источник снимка: manual (по умолчанию) или auto"
+// @Success 201 {object} models.Summary
+// @Failure 400 {string} string "Некорректный trigger"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /summary/snapshot [post]
 
-	summary := Summary{
-		TotalInput:     math.Round(totalInput*100) / 100,
-		TotalOutput:    math.Round(totalOutput*100) / 100,
-		Turnover:       math.Round(turnover*100) / 100,
-		TotalBuys:      math.Round(totalBuys*100) / 100,
-		TotalSells:     math.Round(totalSells*100) / 100,
-		PortfolioValue: math.Round(portfolioValue*100) / 100,
-		Commissions:    math.Round(commissions*100) / 100,
-		Taxes:          math.Round(taxes*100) / 100,
-		NetStockProfit: math.Round(netProfit*100) / 100,
+func (h *Handler) SnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	trigger := r.URL.Query().Get("trigger")
+	switch trigger {
+	case "":
+		trigger = models.SummaryTriggerManual
+	case models.SummaryTriggerManual, models.SummaryTriggerAuto:
+	default:
+		http.Error(w, "trigger должен быть manual или auto", http.StatusBadRequest)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(summary); err != nil {
-		http.Error(w, "Ошибка кодирования", http.StatusInternalServerError)
+	saved, err := h.app.SaveSnapshot(r.Context(), trigger)
+	if err != nil {
+		http.Error(w, "Ошибка сохранения снимка: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
+	writeJSON(w, http.StatusCreated, saved)
 }
 
-// @Summary Сохранение отчёта
-// @Description Сохраняет отчёт, переданный в теле запроса
+// @Summary Сохранение отчёта (admin)
+// @Description Сохраняет отчёт, переданный в теле запроса. Только для роли admin.
+// @Description Поля проверяются на согласованность: turnover = total_buys + total_sells,
+// @Description net_stock_profit = total_sells + portfolio_value - total_buys - commissions - taxes.
 // @Tags summary
 // @Accept json
 // @Produce json
 // @Param summary body models.Summary true "Данные отчёта"
 // @Success 200 {string} string "Summary saved successfully"
 // @Failure 400 {string} string "Invalid JSON"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 422 {string} string "Несогласованные поля отчёта"
 // @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
 // @Router /summary/save [post]
 
 func (h *Handler) SaveSummaryHandler(w http.ResponseWriter, r *http.Request) {
@@ -174,8 +166,18 @@ func (h *Handler) SaveSummaryHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
+	if msg := validateSummary(summary); msg != "" {
+		http.Error(w, msg, http.StatusUnprocessableEntity)
+		return
+	}
+	if summary.Trigger == "" {
+		summary.Trigger = models.SummaryTriggerManual
+	}
+	if summary.Currency == "" {
+		summary.Currency = "rub"
+	}
 
-	if err := h.app.Repo.SaveSummary(r.Context(), summary); err != nil {
+	if _, err := h.app.Repo.SaveSummary(r.Context(), summary); err != nil {
 
 		http.Error(w, "Failed to save summary: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -185,26 +187,163 @@ func (h *Handler) SaveSummaryHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Summary saved successfully"))
 }
 
+// validateSummary проверяет, что поля отчёта не противоречат друг другу.
+func validateSummary(s models.Summary) string {
+	const eps = 0.01
+	for name, v := range map[string]float64{
+		"total_input": s.TotalInput, "total_output": s.TotalOutput, "turnover": s.Turnover,
+		"total_buys": s.TotalBuys, "total_sells": s.TotalSells, "portfolio_value": s.PortfolioValue,
+	} {
+		if v < 0 {
+			return name + " не может быть отрицательным"
+		}
+	}
+	if math.Abs(s.Turnover-(s.TotalBuys+s.TotalSells)) > eps {
+		return "turnover должен быть равен total_buys + total_sells"
+	}
+	netProfit := s.TotalSells + s.PortfolioValue - s.TotalBuys - s.Commissions - s.Taxes
+	if math.Abs(s.NetStockProfit-netProfit) > eps {
+		return "net_stock_profit должен быть равен total_sells + portfolio_value - total_buys - commissions - taxes"
+	}
+	if len(s.Instruments) > 0 {
+		var value float64
+		for _, inst := range s.Instruments {
+			value += inst.Value
+		}
+		if math.Abs(value-s.PortfolioValue) > eps*float64(len(s.Instruments)) {
+			return "portfolio_value должен быть равен сумме value по instruments"
+		}
+	}
+	if s.PeriodFrom != nil && s.PeriodTo != nil && s.PeriodFrom.After(*s.PeriodTo) {
+		return "period_from не может быть позже period_to"
+	}
+	return ""
+}
+
 // @Summary Получение отчётов
-// @Description Возвращает все записи или за конкретную дату
+// @Description Возвращает сохранённые снимки с фильтром по периоду, пагинацией и агрегацией.
+// @Description Курсор следующей страницы возвращается в заголовке X-Next-Cursor.
 // @Tags summary
 // @Produce json
-// @Param date query string false "Дата в формате YYYY-MM-DD"
+// @Param date query string false "Дата в формате YYYY-MM-DD (один день), нельзя вместе с from и to"
+// @Param from query string false "Начало периода, YYYY-MM-DD или RFC3339"
+// @Param to query string false "Конец периода, YYYY-MM-DD (день включительно) или RFC3339"
+// @Param account_id query string false "ID счёта"
+// @Param aggregate query string false "Последний снимок каждого счёта за период: day, week или month"
+// @Param order query string false "asc или desc (по умолчанию desc)"
+// @Param limit query int false "Размер страницы (по умолчанию 500, не больше 5000)"
+// @Param cursor query string false "Курсор из X-Next-Cursor"
 // @Success 200 {array} models.Summary
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы"
+// @Failure 400 {string} string "Некорректные параметры"
 // @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
 // @Router /summaries [get]
 
 func (h *Handler) GetSummariesHandler(w http.ResponseWriter, r *http.Request) {
-	queryDate := r.URL.Query().Get("date") // формат: YYYY-MM-DD
+	q, err := parseSummaryQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	summaries, err := h.app.Repo.GetSummaries(r.Context(), queryDate)
+	summaries, next, err := h.app.Repo.GetSummaries(r.Context(), q)
 	if err != nil {
 		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if summaries == nil {
+		summaries = []models.Summary{}
+	}
+	if next != nil {
+		w.Header().Set("X-Next-Cursor", next.Encode())
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(summaries); err != nil {
 		http.Error(w, "Ошибка кодирования JSON", http.StatusInternalServerError)
 	}
 }
+
+const (
+	defaultSummariesLimit = 500
+	maxSummariesLimit     = 5000
+)
+
+func parseSummaryQuery(v url.Values) (repository.SummaryQuery, error) {
+	q := repository.SummaryQuery{
+		AccountID: v.Get("account_id"),
+		Aggregate: v.Get("aggregate"),
+		Desc:      true,
+		Limit:     defaultSummariesLimit,
+	}
+
+	if date := v.Get("date"); date != "" { // формат: YYYY-MM-DD
+		if v.Get("from") != "" || v.Get("to") != "" {
+			return q, fmt.Errorf("date нельзя указывать вместе с from или to")
+		}
+		start, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return q, fmt.Errorf("некорректная дата %s", date)
+		}
+		end := start.Add(24 * time.Hour)
+		q.From, q.To = &start, &end
+	}
+	if from := v.Get("from"); from != "" {
+		t, _, err := parseDateParam(from)
+		if err != nil {
+			return q, fmt.Errorf("некорректный from: %s", from)
+		}
+		q.From = &t
+	}
+	if to := v.Get("to"); to != "" {
+		t, dateOnly, err := parseDateParam(to)
+		if err != nil {
+			return q, fmt.Errorf("некорректный to: %s", to)
+		}
+		if dateOnly {
+			t = t.Add(24 * time.Hour)
+		}
+		q.To = &t
+	}
+
+	switch q.Aggregate {
+	case "", "day", "week", "month":
+	default:
+		return q, fmt.Errorf("aggregate должен быть day, week или month")
+	}
+
+	switch v.Get("order") {
+	case "", "desc":
+	case "asc":
+		q.Desc = false
+	default:
+		return q, fmt.Errorf("order должен быть asc или desc")
+	}
+
+	if limit := v.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxSummariesLimit {
+			return q, fmt.Errorf("limit должен быть от 1 до %d", maxSummariesLimit)
+		}
+		q.Limit = n
+	}
+
+	if cursor := v.Get("cursor"); cursor != "" {
+		c, err := repository.DecodeSummaryCursor(cursor)
+		if err != nil {
+			return q, err
+		}
+		q.Cursor = c
+	}
+	return q, nil
+}
+
+// parseDateParam принимает YYYY-MM-DD или RFC3339; dateOnly сообщает, что время не указано.
+func parseDateParam(s string) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, s)
+	return t, false, err
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseSummaryQuery(t *testing.T) {
+	q, err := parseSummaryQuery(url.Values{"from": {"2025-01-01"}, "to": {"2025-01-31"}, "order": {"asc"}, "limit": {"10"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.From.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) || !q.To.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("from/to = %v/%v, want 2025-01-01 and the day after 2025-01-31", q.From, q.To)
+	}
+	if q.Desc || q.Limit != 10 {
+		t.Errorf("desc = %v, limit = %d", q.Desc, q.Limit)
+	}
+
+	q, err = parseSummaryQuery(url.Values{"date": {"2025-03-10"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.To.Sub(*q.From) != 24*time.Hour || !q.Desc || q.Limit != defaultSummariesLimit {
+		t.Errorf("date query = %+v", q)
+	}
+}
+
+func TestParseSummaryQueryErrors(t *testing.T) {
+	for _, v := range []url.Values{
+		{"date": {"2025-03-10"}, "from": {"2025-03-01"}},
+		{"date": {"2025-03-10"}, "to": {"2025-03-31"}},
+		{"date": {"10.03.2025"}},
+		{"from": {"yesterday"}},
+		{"aggregate": {"year"}},
+		{"order": {"up"}},
+		{"limit": {"0"}},
+		{"limit": {"5001"}},
+		{"cursor": {"!!!"}},
+	} {
+		if _, err := parseSummaryQuery(v); err == nil {
+			t.Errorf("parseSummaryQuery(%v) succeeded, want error", v)
+		}
+	}
+}
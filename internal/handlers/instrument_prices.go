@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type manualPriceRequest struct {
+	Price float64 `json:"price"`
+}
+
+// @Summary Известные цены инструментов
+// @Description Возвращает назначенные вручную цены и последние полученные из API (обновляются при сохранении снимка отчёта и проверке оповещений)
+// @Tags corporate-actions
+// @Produce json
+// @Success 200 {object} map[string]models.InstrumentPrice
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /instrument-prices [get]
+
+func (h *Handler) InstrumentPricesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	prices, err := h.app.Repo.GetInstrumentPrices(r.Context())
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, prices)
+}
+
+func (h *Handler) InstrumentPriceHandler(w http.ResponseWriter, r *http.Request) {
+	figi := strings.TrimPrefix(r.URL.Path, "/instrument-prices/")
+	if figi == "" {
+		http.Error(w, "FIGI не указан", http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		h.setManualPrice(w, r, figi)
+	case http.MethodDelete:
+		h.resetManualPrice(w, r, figi)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// @Summary Назначение цены вручную
+// @Description Цена используется для делистингованных и замороженных бумаг, а также когда API не отдаёт цену
+// @Tags corporate-actions
+// @Accept json
+// @Param figi path string true "FIGI инструмента"
+// @Param price body manualPriceRequest true "Цена"
+// @Success 204
+// @Failure 400 {string} string "Некорректная цена"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /instrument-prices/{figi} [put]
+
+func (h *Handler) setManualPrice(w http.ResponseWriter, r *http.Request, figi string) {
+	var req manualPriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Price < 0 {
+		http.Error(w, "Цена не может быть отрицательной", http.StatusBadRequest)
+		return
+	}
+	if err := h.app.Repo.SetManualPrice(r.Context(), figi, &req.Price); err != nil {
+		http.Error(w, "Ошибка при сохранении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Сброс ручной цены
+// @Tags corporate-actions
+// @Param figi path string true "FIGI инструмента"
+// @Success 204
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /instrument-prices/{figi} [delete]
+
+func (h *Handler) resetManualPrice(w http.ResponseWriter, r *http.Request, figi string) {
+	if err := h.app.Repo.SetManualPrice(r.Context(), figi, nil); err != nil {
+		http.Error(w, "Ошибка при сохранении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/scheduler"
+)
+
+// @Summary Фоновые задачи
+// @Description Возвращает задачи планировщика: расписание, время и результат последнего запуска, время следующего
+// @Tags jobs
+// @Produce json
+// @Success 200 {array} scheduler.JobStatus
+// @Security ApiKeyAuth
+// @Router /jobs [get]
+
+func (h *Handler) JobsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	jobs := []scheduler.JobStatus{}
+	if h.app.Jobs != nil {
+		jobs = h.app.Jobs.Jobs()
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (h *Handler) JobHandler(w http.ResponseWriter, r *http.Request) {
+	name, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/jobs/"), "/")
+	switch {
+	case sub == "run" && r.Method == http.MethodPost:
+		h.runJob(w, r, name)
+	case sub == "runs" && r.Method == http.MethodGet:
+		h.getJobRuns(w, r, name)
+	case sub == "run" || sub == "runs":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// @Summary Запуск задачи вне расписания
+// @Description Запускает задачу в фоне и сразу отвечает; результат виден в GET /jobs.
+// @Description Если задачу в это время выполняет другая реплика, запуск пропускается.
+// @Tags jobs
+// @Param name path string true "Имя задачи: autosave, retention, equity, alerts, digests"
+// @Success 202
+// @Failure 404 {string} string "Задача не найдена"
+// @Failure 409 {string} string "Задача уже выполняется"
+// @Security ApiKeyAuth
+// @Router /jobs/{name}/run [post]
+
+func (h *Handler) runJob(w http.ResponseWriter, r *http.Request, name string) {
+	if h.app.Jobs == nil {
+		http.Error(w, scheduler.ErrUnknownJob.Error(), http.StatusNotFound)
+		return
+	}
+
+	err := h.app.Jobs.Trigger(name)
+	switch {
+	case errors.Is(err, scheduler.ErrUnknownJob):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, scheduler.ErrJobRunning):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// @Summary История запусков задачи
+// @Description Возвращает запуски задачи на всех репликах из таблицы job_runs, начиная с новых
+// @Tags jobs
+// @Produce json
+// @Param name path string true "Имя задачи"
+// @Param limit query int false "Количество записей (по умолчанию 50)"
+// @Success 200 {array} models.JobRun
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /jobs/{name}/runs [get]
+
+func (h *Handler) getJobRuns(w http.ResponseWriter, r *http.Request, name string) {
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Некорректный limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.app.Repo.GetJobRuns(r.Context(), name, limit)
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if runs == nil {
+		runs = []models.JobRun{}
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
+// @Summary История запусков задач
+// @Description Возвращает последние запуски всех задач на всех репликах
+// @Tags jobs
+// @Produce json
+// @Param limit query int false "Количество записей (по умолчанию 50)"
+// @Success 200 {array} models.JobRun
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /job-runs [get]
+
+func (h *Handler) JobRunsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.getJobRuns(w, r, "")
+}
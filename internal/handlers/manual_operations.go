@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"tinvest_report/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (h *Handler) ManualOperationsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listManualOperations(w, r)
+	case http.MethodPost:
+		h.createManualOperation(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) ManualOperationHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/manual-operations/")
+	idStr, sub, _ := strings.Cut(path, "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Некорректный ID операции", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case sub == "audit" && r.Method == http.MethodGet:
+		h.getManualOperationAudit(w, r, id)
+	case sub != "":
+		http.NotFound(w, r)
+	case r.Method == http.MethodGet:
+		h.getManualOperation(w, r, id)
+	case r.Method == http.MethodPut:
+		h.updateManualOperation(w, r, id)
+	case r.Method == http.MethodDelete:
+		h.deleteManualOperation(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// @Summary Ручные операции
+// @Description Возвращает все ручные операции
+// @Tags manual
+// @Produce json
+// @Success 200 {array} models.ManualOperation
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /manual-operations [get]
+
+func (h *Handler) listManualOperations(w http.ResponseWriter, r *http.Request) {
+	ops, err := h.app.Repo.ListManualOperations(r.Context())
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, ops)
+}
+
+// @Summary Добавление ручной операции
+// @Description Сохраняет операцию, которой нет в API (OTC-перевод, подарок акций, перенос от другого брокера)
+// @Tags manual
+// @Accept json
+// @Produce json
+// @Param operation body models.ManualOperation true "Операция"
+// @Success 201 {object} models.ManualOperation
+// @Failure 400 {string} string "Некорректная операция"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /manual-operations [post]
+
+func (h *Handler) createManualOperation(w http.ResponseWriter, r *http.Request) {
+	op, ok := decodeManualOperation(w, r)
+	if !ok {
+		return
+	}
+	created, err := h.app.Repo.CreateManualOperation(r.Context(), op, userName(r))
+	if err != nil {
+		http.Error(w, "Ошибка при сохранении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// @Summary Ручная операция
+// @Description Возвращает ручную операцию по ID
+// @Tags manual
+// @Produce json
+// @Param id path int true "ID операции"
+// @Success 200 {object} models.ManualOperation
+// @Failure 404 {string} string "Операция не найдена"
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /manual-operations/{id} [get]
+
+func (h *Handler) getManualOperation(w http.ResponseWriter, r *http.Request, id int) {
+	op, err := h.app.Repo.GetManualOperation(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Операция не найдена", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, op)
+}
+
+// @Summary Изменение ручной операции
+// @Description Обновляет ручную операцию, предыдущее состояние сохраняется в журнале
+// @Tags manual
+// @Accept json
+// @Produce json
+// @Param id path int true "ID операции"
+// @Param operation body models.ManualOperation true "Операция"
+// @Success 200 {object} models.ManualOperation
+// @Failure 400 {string} string "Некорректная операция"
+// @Failure 404 {string} string "Операция не найдена"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /manual-operations/{id} [put]
+
+func (h *Handler) updateManualOperation(w http.ResponseWriter, r *http.Request, id int) {
+	op, ok := decodeManualOperation(w, r)
+	if !ok {
+		return
+	}
+	updated, err := h.app.Repo.UpdateManualOperation(r.Context(), id, op, userName(r))
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Операция не найдена", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка при сохранении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// @Summary Удаление ручной операции
+// @Description Удаляет ручную операцию, её последнее состояние сохраняется в журнале
+// @Tags manual
+// @Param id path int true "ID операции"
+// @Success 204
+// @Failure 404 {string} string "Операция не найдена"
+// @Failure 500 {string} string "Ошибка при удалении"
+// @Security ApiKeyAuth
+// @Router /manual-operations/{id} [delete]
+
+func (h *Handler) deleteManualOperation(w http.ResponseWriter, r *http.Request, id int) {
+	err := h.app.Repo.DeleteManualOperation(r.Context(), id, userName(r))
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Операция не найдена", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка при удалении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Журнал изменений ручной операции
+// @Description Возвращает историю создания, изменения и удаления операции с именем пользователя, выполнившего изменение
+// @Tags manual
+// @Produce json
+// @Param id path int true "ID операции"
+// @Success 200 {array} models.ManualOperationAudit
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /manual-operations/{id}/audit [get]
+
+func (h *Handler) getManualOperationAudit(w http.ResponseWriter, r *http.Request, id int) {
+	entries, err := h.app.Repo.GetManualOperationAudit(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func decodeManualOperation(w http.ResponseWriter, r *http.Request) (models.ManualOperation, bool) {
+	var op models.ManualOperation
+	if err := json.NewDecoder(r.Body).Decode(&op); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return op, false
+	}
+	if msg := validateManualOperation(op); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return op, false
+	}
+	if op.Currency == "" {
+		op.Currency = "rub"
+	}
+	return op, true
+}
+
+// validateManualOperation проверяет поля, от которых зависит воспроизведение операций:
+// для сделок и переводов бумаг нужны FIGI и количество, а знак суммы должен совпадать
+// с направлением движения денег, как в операциях Tinkoff.
+func validateManualOperation(op models.ManualOperation) string {
+	if !strings.HasPrefix(op.OperationType, "OPERATION_TYPE_") {
+		return "operation_type должен быть типом операции Tinkoff (OPERATION_TYPE_*)"
+	}
+	if op.Date.IsZero() {
+		return "Не указана дата операции"
+	}
+	switch op.OperationType {
+	case "OPERATION_TYPE_BUY", "OPERATION_TYPE_SELL",
+		"OPERATION_TYPE_INPUT_SECURITIES", "OPERATION_TYPE_OUTPUT_SECURITIES":
+		if op.FIGI == "" {
+			return "FIGI не указан"
+		}
+		if op.Quantity <= 0 {
+			return "quantity должен быть больше нуля"
+		}
+		if op.Price < 0 {
+			return "price не может быть отрицательной"
+		}
+	}
+	switch op.OperationType {
+	case "OPERATION_TYPE_BUY", "OPERATION_TYPE_OUTPUT", "OPERATION_TYPE_OUT_MULTI":
+		if op.FloatPayment >= 0 {
+			return "float_payment для " + op.OperationType + " должен быть отрицательным"
+		}
+	case "OPERATION_TYPE_SELL", "OPERATION_TYPE_INPUT", "OPERATION_TYPE_INP_MULTI":
+		if op.FloatPayment <= 0 {
+			return "float_payment для " + op.OperationType + " должен быть положительным"
+		}
+	case "OPERATION_TYPE_BROKER_FEE", "OPERATION_TYPE_TRACK_MFEE", "OPERATION_TYPE_TRACK_PFEE", "OPERATION_TYPE_TAX":
+		if op.FloatPayment > 0 {
+			return "float_payment для " + op.OperationType + " не может быть положительным"
+		}
+	case "OPERATION_TYPE_INPUT_SECURITIES", "OPERATION_TYPE_OUTPUT_SECURITIES":
+		if op.FloatPayment != 0 {
+			return "float_payment для перевода бумаг должен быть равен нулю, стоимость указывается в price"
+		}
+	}
+	return ""
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
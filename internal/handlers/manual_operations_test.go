@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tinvest_report/internal/models"
+)
+
+// Проверки выполняются до обращения к БД, поэтому обработчику не нужно приложение.
+func TestManualOperationValidation(t *testing.T) {
+	h := &Handler{}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   string
+	}{
+		{"invalid json", http.MethodPost, "/manual-operations", `{`, "Invalid JSON"},
+		{"unknown type", http.MethodPost, "/manual-operations", `{"operation_type": "BUY", "date": "2024-01-01T00:00:00Z"}`, "operation_type"},
+		{"no date", http.MethodPost, "/manual-operations", `{"operation_type": "OPERATION_TYPE_BUY"}`, "дата"},
+		{"update without date", http.MethodPut, "/manual-operations/1", `{"operation_type": "OPERATION_TYPE_BUY"}`, "дата"},
+		{"buy without figi", http.MethodPost, "/manual-operations", `{"operation_type": "OPERATION_TYPE_BUY", "date": "2024-01-01T00:00:00Z", "quantity": 1, "float_payment": -100}`, "FIGI"},
+		{"sell without quantity", http.MethodPost, "/manual-operations", `{"operation_type": "OPERATION_TYPE_SELL", "date": "2024-01-01T00:00:00Z", "figi": "BBG000B9XRY4", "float_payment": 100}`, "quantity"},
+		{"transfer with negative quantity", http.MethodPost, "/manual-operations", `{"operation_type": "OPERATION_TYPE_INPUT_SECURITIES", "date": "2024-01-01T00:00:00Z", "figi": "BBG000B9XRY4", "quantity": -5}`, "quantity"},
+		{"buy with positive payment", http.MethodPost, "/manual-operations", `{"operation_type": "OPERATION_TYPE_BUY", "date": "2024-01-01T00:00:00Z", "figi": "BBG000B9XRY4", "quantity": 1, "float_payment": 100}`, "отрицательным"},
+		{"sell with negative payment", http.MethodPost, "/manual-operations", `{"operation_type": "OPERATION_TYPE_SELL", "date": "2024-01-01T00:00:00Z", "figi": "BBG000B9XRY4", "quantity": 1, "float_payment": -100}`, "положительным"},
+		{"output with positive payment", http.MethodPost, "/manual-operations", `{"operation_type": "OPERATION_TYPE_OUTPUT", "date": "2024-01-01T00:00:00Z", "float_payment": 100}`, "отрицательным"},
+		{"transfer with payment", http.MethodPost, "/manual-operations", `{"operation_type": "OPERATION_TYPE_INPUT_SECURITIES", "date": "2024-01-01T00:00:00Z", "figi": "BBG000B9XRY4", "quantity": 5, "float_payment": 500}`, "price"},
+		{"bad id", http.MethodGet, "/manual-operations/abc", ``, "ID операции"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			if strings.Count(tt.path, "/") > 1 {
+				h.ManualOperationHandler(w, r)
+			} else {
+				h.ManualOperationsHandler(w, r)
+			}
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400 (%s)", w.Code, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("body = %q, want it to mention %q", w.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeManualOperationDefaultsCurrency(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/manual-operations",
+		strings.NewReader(`{"operation_type": "OPERATION_TYPE_INPUT", "date": "2024-01-01T00:00:00Z", "float_payment": 1000}`))
+	op, ok := decodeManualOperation(httptest.NewRecorder(), r)
+	if !ok {
+		t.Fatal("valid operation rejected")
+	}
+	if op.Currency != "rub" {
+		t.Errorf("currency = %q, want rub", op.Currency)
+	}
+}
+
+func TestValidateManualOperationAcceptsTrades(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, op := range []models.ManualOperation{
+		{OperationType: "OPERATION_TYPE_BUY", Date: day, FIGI: "BBG000B9XRY4", Quantity: 2, FloatPayment: -200},
+		{OperationType: "OPERATION_TYPE_SELL", Date: day, FIGI: "BBG000B9XRY4", Quantity: 2, FloatPayment: 210},
+		{OperationType: "OPERATION_TYPE_INPUT_SECURITIES", Date: day, FIGI: "BBG000B9XRY4", Quantity: 5, Price: 95},
+		{OperationType: "OPERATION_TYPE_BROKER_FEE", Date: day, FloatPayment: -1},
+		{OperationType: "OPERATION_TYPE_DIVIDEND", Date: day, FIGI: "BBG000B9XRY4", FloatPayment: 30},
+	} {
+		if msg := validateManualOperation(op); msg != "" {
+			t.Errorf("validateManualOperation(%s) = %q, want ok", op.OperationType, msg)
+		}
+	}
+}
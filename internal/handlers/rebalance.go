@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/service"
+)
+
+type allocationTargetRequest struct {
+	Key    string  `json:"key"`
+	Weight float64 `json:"weight"`
+}
+
+func (h *Handler) TargetsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listTargets(w, r)
+	case http.MethodPut:
+		h.replaceTargets(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// @Summary Целевые доли
+// @Description Возвращает целевые доли инструментов или групп; без dimension — все измерения
+// @Tags portfolio
+// @Produce json
+// @Param dimension query string false "figi, type, sector, currency или country"
+// @Success 200 {array} models.AllocationTarget
+// @Failure 400 {string} string "Неизвестное измерение"
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /targets [get]
+
+func (h *Handler) listTargets(w http.ResponseWriter, r *http.Request) {
+	dimension := r.URL.Query().Get("dimension")
+	if dimension != "" && !validTargetDimension(dimension) {
+		http.Error(w, "Неизвестное измерение "+dimension, http.StatusBadRequest)
+		return
+	}
+	targets, err := h.app.Repo.GetAllocationTargets(r.Context(), dimension)
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if targets == nil {
+		targets = []models.AllocationTarget{}
+	}
+	writeJSON(w, http.StatusOK, targets)
+}
+
+// @Summary Задание целевых долей
+// @Description Заменяет все целевые доли измерения. Доля — от 0 до 1, сумма не больше 1, остаток — деньги
+// @Tags portfolio
+// @Accept json
+// @Param dimension query string true "figi, type, sector, currency или country"
+// @Param targets body []allocationTargetRequest true "Целевые доли"
+// @Success 204
+// @Failure 400 {string} string "Некорректные доли"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /targets [put]
+
+func (h *Handler) replaceTargets(w http.ResponseWriter, r *http.Request) {
+	dimension := r.URL.Query().Get("dimension")
+	if !validTargetDimension(dimension) {
+		http.Error(w, "Неизвестное измерение "+dimension, http.StatusBadRequest)
+		return
+	}
+
+	var req []allocationTargetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	targets, err := validateTargets(dimension, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.app.Repo.ReplaceAllocationTargets(r.Context(), dimension, targets); err != nil {
+		http.Error(w, "Ошибка при сохранении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Предложение ребалансировки
+// @Description Сделки в целых лотах, приводящие портфель к целевым долям измерения. Сначала продажи, затем покупки в пределах свободных денег и пополнения. Позиции без справочных данных в групповом режиме не продаются, если для unknown нет цели
+// @Tags portfolio
+// @Produce json
+// @Param by query string false "Измерение целевых долей, по умолчанию figi"
+// @Param min_trade query number false "Минимальная сумма сделки"
+// @Param deposit query number false "Сумма пополнения"
+// @Param deposit_only query bool false "Только покупки, без продаж"
+// @Success 200 {object} models.RebalancePlan
+// @Failure 400 {string} string "Некорректные параметры"
+// @Failure 404 {string} string "Целевые доли не заданы"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /rebalance [get]
+
+func (h *Handler) RebalanceHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := service.RebalanceOptions{
+		Dimension:   q.Get("by"),
+		DepositOnly: q.Get("deposit_only") == "true",
+	}
+	if opts.Dimension == "" {
+		opts.Dimension = models.TargetByFIGI
+	}
+	if !validTargetDimension(opts.Dimension) {
+		http.Error(w, "Неизвестное измерение "+opts.Dimension, http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if v := q.Get("min_trade"); v != "" {
+		if opts.MinTrade, err = strconv.ParseFloat(v, 64); err != nil || opts.MinTrade < 0 {
+			http.Error(w, "Некорректный min_trade", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("deposit"); v != "" {
+		if opts.Deposit, err = strconv.ParseFloat(v, 64); err != nil || opts.Deposit < 0 {
+			http.Error(w, "Некорректный deposit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	plan, err := h.app.Rebalance(r.Context(), opts)
+	if errors.Is(err, service.ErrNoTargets) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка расчёта ребалансировки: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, plan)
+}
+
+func validTargetDimension(dimension string) bool {
+	switch dimension {
+	case models.TargetByFIGI, models.TargetByType, models.TargetBySector,
+		models.TargetByCurrency, models.TargetByCountry:
+		return true
+	}
+	return false
+}
+
+func validateTargets(dimension string, req []allocationTargetRequest) ([]models.AllocationTarget, error) {
+	seen := make(map[string]bool, len(req))
+	targets := make([]models.AllocationTarget, 0, len(req))
+	var sum float64
+	for _, t := range req {
+		if t.Key == "" {
+			return nil, errors.New("key обязателен")
+		}
+		if seen[t.Key] {
+			return nil, fmt.Errorf("%s указан дважды", t.Key)
+		}
+		if t.Weight <= 0 || t.Weight > 1 {
+			return nil, fmt.Errorf("доля %s должна быть больше 0 и не больше 1", t.Key)
+		}
+		seen[t.Key] = true
+		sum += t.Weight
+		targets = append(targets, models.AllocationTarget{Dimension: dimension, Key: t.Key, Weight: t.Weight})
+	}
+	if sum > 1+1e-9 {
+		return nil, fmt.Errorf("сумма долей %.4f больше 1", sum)
+	}
+	return targets, nil
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"tinvest_report/internal/models"
+)
+
+func (h *Handler) RetentionHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getRetentionRuns(w, r)
+	case http.MethodPost:
+		h.runRetention(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// @Summary История очистки снимков
+// @Description Возвращает последние прогоны прореживания снимков и список удалённых записей
+// @Tags summary
+// @Produce json
+// @Param limit query int false "Количество прогонов (по умолчанию 20)"
+// @Success 200 {array} models.RetentionRun
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /summaries/retention [get]
+
+func (h *Handler) getRetentionRuns(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Некорректный limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.app.Repo.GetRetentionRuns(r.Context(), limit)
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
+// @Summary Очистка снимков
+// @Description Прореживает снимки по настроенной политике хранения. По умолчанию dry run — только отчёт о том, что будет удалено.
+// @Description Задача retention удаляет снимки только после того, как политика с теми же параметрами хотя бы раз запущена здесь.
+// @Tags summary
+// @Produce json
+// @Param dry_run query bool false "false — удалить снимки (по умолчанию true)"
+// @Success 200 {object} models.RetentionRun
+// @Failure 500 {string} string "Ошибка очистки"
+// @Security ApiKeyAuth
+// @Router /summaries/retention [post]
+
+func (h *Handler) runRetention(w http.ResponseWriter, r *http.Request) {
+	policy := h.app.Retention
+	policy.DryRun = r.URL.Query().Get("dry_run") != "false"
+
+	run, err := h.app.ApplyRetention(r.Context(), policy, models.SummaryTriggerManual)
+	if err != nil {
+		http.Error(w, "Ошибка очистки снимков: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
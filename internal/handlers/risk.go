@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"tinvest_report/internal/service"
+)
+
+// @Summary Риск-метрики
+// @Description Годовая волатильность, максимальная просадка и её длительность, коэффициенты Шарпа и Сортино и бета к бенчмарку по ряду капитала за период
+// @Tags equity
+// @Produce json
+// @Param from query string false "Начало периода, YYYY-MM-DD"
+// @Param to query string false "Конец периода включительно, YYYY-MM-DD"
+// @Param risk_free_rate query number false "Годовая безрисковая ставка, 0.16 = 16%"
+// @Param benchmark query string false "FIGI бенчмарка для беты"
+// @Success 200 {object} models.RiskMetrics
+// @Failure 400 {string} string "Некорректные параметры"
+// @Failure 404 {string} string "Нет данных о капитале"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /risk [get]
+
+func (h *Handler) RiskHandler(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parsePeriod(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	riskFreeRate := h.app.Risk.RiskFreeRate
+	if v := r.URL.Query().Get("risk_free_rate"); v != "" {
+		riskFreeRate, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			http.Error(w, "Некорректный risk_free_rate", http.StatusBadRequest)
+			return
+		}
+	}
+	benchmark := h.app.Risk.BenchmarkFIGI
+	if v := r.URL.Query().Get("benchmark"); v != "" {
+		benchmark = v
+	}
+
+	metrics, err := h.app.RiskMetrics(r.Context(), from, to, riskFreeRate, benchmark)
+	if errors.Is(err, service.ErrNoEquity) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка расчёта риск-метрик: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, metrics)
+}
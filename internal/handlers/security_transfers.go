@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/service"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// @Summary Переводы бумаг
+// @Description Возвращает операции ввода и вывода бумаг и указанную для них стоимость
+// @Tags transfers
+// @Produce json
+// @Success 200 {array} models.SecurityTransfer
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /security-transfers [get]
+
+func (h *Handler) SecurityTransfersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	transfers, err := h.app.GetSecurityTransfers(r.Context())
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, transfers)
+}
+
+func (h *Handler) SecurityTransferHandler(w http.ResponseWriter, r *http.Request) {
+	operationID := strings.TrimPrefix(r.URL.Path, "/security-transfers/")
+	if operationID == "" {
+		http.Error(w, "ID операции не указан", http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		h.saveSecurityTransferBasis(w, r, operationID)
+	case http.MethodDelete:
+		h.deleteSecurityTransferBasis(w, r, operationID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// @Summary Стоимость перевода бумаг
+// @Description Для ввода — цена и дата приобретения у предыдущего брокера, для вывода — оценка бумаг на дату вывода
+// @Tags transfers
+// @Accept json
+// @Param operation_id path string true "ID операции ввода/вывода бумаг"
+// @Param basis body models.SecurityTransferBasis true "Стоимость"
+// @Success 204
+// @Failure 400 {string} string "Некорректная стоимость или операция не является переводом бумаг"
+// @Failure 404 {string} string "Операция не найдена"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /security-transfers/{operation_id} [put]
+
+func (h *Handler) saveSecurityTransferBasis(w http.ResponseWriter, r *http.Request, operationID string) {
+	var basis models.SecurityTransferBasis
+	if err := json.NewDecoder(r.Body).Decode(&basis); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if basis.Price < 0 {
+		http.Error(w, "Цена не может быть отрицательной", http.StatusBadRequest)
+		return
+	}
+	basis.OperationID = operationID
+
+	err := h.app.SaveSecurityTransferBasis(r.Context(), basis)
+	switch {
+	case errors.Is(err, service.ErrOperationNotFound):
+		http.Error(w, "Операция не найдена", http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrNotSecurityTransfer):
+		http.Error(w, "Стоимость указывается только для действующей операции ввода или вывода бумаг", http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, "Ошибка при сохранении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Удаление стоимости перевода бумаг
+// @Tags transfers
+// @Param operation_id path string true "ID операции ввода/вывода бумаг"
+// @Success 204
+// @Failure 404 {string} string "Стоимость не указана"
+// @Failure 500 {string} string "Ошибка при удалении"
+// @Security ApiKeyAuth
+// @Router /security-transfers/{operation_id} [delete]
+
+func (h *Handler) deleteSecurityTransferBasis(w http.ResponseWriter, r *http.Request, operationID string) {
+	err := h.app.Repo.DeleteSecurityTransferBasis(r.Context(), operationID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Стоимость не указана", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка при удалении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Открытые лоты
+// @Description Возвращает открытые лоты по FIFO с датами приобретения и признаком права на ЛДВ
+// @Tags transfers
+// @Produce json
+// @Param tag query string false "Метка стратегии; untagged — операции без меток"
+// @Success 200 {array} models.Lot
+// @Failure 500 {string} string "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /lots [get]
+
+func (h *Handler) LotsHandler(w http.ResponseWriter, r *http.Request) {
+	lots, err := h.app.GetOpenLots(r.Context(), r.URL.Query().Get("tag"))
+	if err != nil {
+		http.Error(w, "Ошибка расчёта лотов: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, lots)
+}
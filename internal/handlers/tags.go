@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+)
+
+// maxTagLength — ограничение длины имени метки.
+const maxTagLength = 64
+
+func (h *Handler) TagsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listTags(w, r)
+	case http.MethodPost:
+		h.saveTag(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// @Summary Метки стратегий
+// @Description Возвращает все метки
+// @Tags tags
+// @Produce json
+// @Success 200 {array} models.Tag
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /tags [get]
+
+func (h *Handler) listTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.app.Repo.ListTags(r.Context())
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+	writeJSON(w, http.StatusOK, tags)
+}
+
+// @Summary Создание метки
+// @Description Создаёт метку или обновляет её описание
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param tag body models.Tag true "Метка"
+// @Success 200 {object} models.Tag
+// @Failure 400 {string} string "Некорректная метка"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /tags [post]
+
+func (h *Handler) saveTag(w http.ResponseWriter, r *http.Request) {
+	var t models.Tag
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := validateTagName(t.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	saved, err := h.app.Repo.SaveTag(r.Context(), t)
+	if err != nil {
+		http.Error(w, "Ошибка при сохранении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// @Summary Удаление метки
+// @Description Удаляет метку вместе с её назначениями инструментам и операциям
+// @Tags tags
+// @Param name path string true "Имя метки"
+// @Success 204
+// @Failure 404 {string} string "Метка не найдена"
+// @Failure 500 {string} string "Ошибка при удалении"
+// @Security ApiKeyAuth
+// @Router /tags/{name} [delete]
+
+func (h *Handler) TagHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/tags/")
+	if name == "" {
+		http.Error(w, "Метка не указана", http.StatusBadRequest)
+		return
+	}
+	err := h.app.Repo.DeleteTag(r.Context(), name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Метка не найдена", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка при удалении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Метки инструментов
+// @Description Возвращает метки, назначенные инструментам, по FIGI
+// @Tags tags
+// @Produce json
+// @Success 200 {object} map[string][]string
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /instrument-tags [get]
+
+func (h *Handler) InstrumentTagsHandler(w http.ResponseWriter, r *http.Request) {
+	h.listAssignments(w, r, func(a models.TagAssignments) map[string][]string { return a.Instruments })
+}
+
+// @Summary Метки операций
+// @Description Возвращает метки, назначенные отдельным операциям, по ID операции
+// @Tags tags
+// @Produce json
+// @Success 200 {object} map[string][]string
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /operation-tags [get]
+
+func (h *Handler) OperationTagsHandler(w http.ResponseWriter, r *http.Request) {
+	h.listAssignments(w, r, func(a models.TagAssignments) map[string][]string { return a.Operations })
+}
+
+func (h *Handler) listAssignments(w http.ResponseWriter, r *http.Request, pick func(models.TagAssignments) map[string][]string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	assignments, err := h.app.Repo.GetTagAssignments(r.Context())
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, pick(assignments))
+}
+
+// @Summary Назначение меток инструменту
+// @Description Заменяет метки инструмента. Метки инструмента применяются ко всем его операциям без собственных меток. Пустой массив снимает метки
+// @Tags tags
+// @Accept json
+// @Param figi path string true "FIGI инструмента"
+// @Param tags body []string true "Метки"
+// @Success 204
+// @Failure 400 {string} string "Некорректные метки"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /instrument-tags/{figi} [put]
+
+func (h *Handler) InstrumentTagHandler(w http.ResponseWriter, r *http.Request) {
+	figi := strings.TrimPrefix(r.URL.Path, "/instrument-tags/")
+	if figi == "" {
+		http.Error(w, "FIGI не указан", http.StatusBadRequest)
+		return
+	}
+	h.assignTags(w, r, figi, h.app.Repo.SetInstrumentTags)
+}
+
+// @Summary Назначение меток операции
+// @Description Заменяет метки операции; они имеют приоритет над метками инструмента. Пустой массив снимает метки
+// @Tags tags
+// @Accept json
+// @Param operation_id path string true "ID операции, для ручных — manual-N"
+// @Param tags body []string true "Метки"
+// @Success 204
+// @Failure 400 {string} string "Некорректные метки"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /operation-tags/{operation_id} [put]
+
+func (h *Handler) OperationTagHandler(w http.ResponseWriter, r *http.Request) {
+	operationID := strings.TrimPrefix(r.URL.Path, "/operation-tags/")
+	if operationID == "" {
+		http.Error(w, "ID операции не указан", http.StatusBadRequest)
+		return
+	}
+	h.assignTags(w, r, operationID, h.app.Repo.SetOperationTags)
+}
+
+func (h *Handler) assignTags(w http.ResponseWriter, r *http.Request, key string,
+	save func(ctx context.Context, key string, tags []string) error) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var tags []string
+	if err := json.NewDecoder(r.Body).Decode(&tags); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	seen := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		if err := validateTagName(t); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if seen[t] {
+			http.Error(w, "Метка "+t+" указана дважды", http.StatusBadRequest)
+			return
+		}
+		seen[t] = true
+	}
+
+	if err := save(r.Context(), key, tags); err != nil {
+		http.Error(w, "Ошибка при сохранении: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateTagName(name string) error {
+	switch {
+	case name == "" || strings.TrimSpace(name) != name:
+		return errors.New("имя метки не может быть пустым или начинаться и заканчиваться пробелом")
+	case strings.Contains(name, "/"):
+		return errors.New("имя метки не может содержать /")
+	case utf8.RuneCountInString(name) > maxTagLength:
+		return fmt.Errorf("имя метки длиннее %d символов", maxTagLength)
+	case name == models.UntaggedGroup:
+		return fmt.Errorf("имя %s зарезервировано", models.UntaggedGroup)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/service"
+)
+
+// maxUserNameLength — ограничение длины имени пользователя.
+const maxUserNameLength = 64
+
+// userRequest — тело создания пользователя и смены роли.
+type userRequest struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+func (h *Handler) UsersHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listUsers(w, r)
+	case http.MethodPost:
+		h.createUser(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) UserHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/users/")
+	idStr, sub, _ := strings.Cut(path, "/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Некорректный ID пользователя", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case sub == "key" && r.Method == http.MethodPost:
+		h.rotateUserKey(w, r, id)
+	case sub != "":
+		http.NotFound(w, r)
+	case r.Method == http.MethodPut:
+		h.updateUserRole(w, r, id)
+	case r.Method == http.MethodDelete:
+		h.deleteUser(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// @Summary Пользователи API
+// @Description Возвращает пользователей с ролями и временем последнего запроса. Только для admin
+// @Tags users
+// @Produce json
+// @Success 200 {array} models.User
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 500 {string} string "Ошибка при получении"
+// @Security ApiKeyAuth
+// @Router /users [get]
+
+func (h *Handler) listUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.app.Repo.ListUsers(r.Context())
+	if err != nil {
+		http.Error(w, "Ошибка получения данных: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if users == nil {
+		users = []models.User{}
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
+// @Summary Создание пользователя
+// @Description Создаёт пользователя с ролью viewer или admin и выпускает ключ API. Ключ возвращается
+// @Description только в этом ответе, в БД хранится его хэш. Только для admin
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body userRequest true "Имя и роль"
+// @Success 201 {object} models.UserKey
+// @Failure 400 {string} string "Некорректные данные"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 409 {string} string "Пользователь уже существует"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /users [post]
+
+func (h *Handler) createUser(w http.ResponseWriter, r *http.Request) {
+	var req userRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > maxUserNameLength {
+		http.Error(w, "Имя пользователя должно быть непустым и не длиннее 64 символов", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.app.CreateUser(r.Context(), req.Name, req.Role)
+	if err != nil {
+		writeUserError(w, err, "Ошибка при сохранении: ")
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// @Summary Смена роли пользователя
+// @Description Меняет роль пользователя; поле name игнорируется. Последнего администратора понизить нельзя. Только для admin
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param user body userRequest true "Новая роль"
+// @Success 200 {object} models.User
+// @Failure 400 {string} string "Некорректная роль"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Пользователь не найден"
+// @Failure 409 {string} string "Последний администратор"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /users/{id} [put]
+
+func (h *Handler) updateUserRole(w http.ResponseWriter, r *http.Request, id int64) {
+	var req userRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	u, err := h.app.SetUserRole(r.Context(), id, req.Role)
+	if err != nil {
+		writeUserError(w, err, "Ошибка при сохранении: ")
+		return
+	}
+	writeJSON(w, http.StatusOK, u)
+}
+
+// @Summary Перевыпуск ключа
+// @Description Выпускает пользователю новый ключ API, старый сразу перестаёт действовать. Только для admin
+// @Tags users
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} models.UserKey
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Пользователь не найден"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Security ApiKeyAuth
+// @Router /users/{id}/key [post]
+
+func (h *Handler) rotateUserKey(w http.ResponseWriter, r *http.Request, id int64) {
+	key, err := h.app.RotateUserKey(r.Context(), id)
+	if err != nil {
+		writeUserError(w, err, "Ошибка при сохранении: ")
+		return
+	}
+	writeJSON(w, http.StatusOK, key)
+}
+
+// @Summary Удаление пользователя
+// @Description Удаляет пользователя вместе с ключом. Последнего администратора удалить нельзя. Только для admin
+// @Tags users
+// @Param id path int true "ID пользователя"
+// @Success 204
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Пользователь не найден"
+// @Failure 409 {string} string "Последний администратор"
+// @Failure 500 {string} string "Ошибка при удалении"
+// @Security ApiKeyAuth
+// @Router /users/{id} [delete]
+
+func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := h.app.DeleteUser(r.Context(), id); err != nil {
+		writeUserError(w, err, "Ошибка при удалении: ")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeUserError отвечает кодом по ошибке сервиса пользователей.
+func writeUserError(w http.ResponseWriter, err error, prefix string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRole):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, "Пользователь не найден", http.StatusNotFound)
+	case errors.Is(err, service.ErrLastAdmin), errors.Is(err, service.ErrUserExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, prefix+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Текущий пользователь
+// @Description Возвращает пользователя, которому принадлежит ключ запроса, и его роль
+// @Tags users
+// @Produce json
+// @Success 200 {object} models.User
+// @Failure 401 {string} string "Требуется ключ API"
+// @Security ApiKeyAuth
+// @Router /me [get]
+
+func (h *Handler) MeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	u, _ := currentUser(r.Context())
+	writeJSON(w, http.StatusOK, u)
+}
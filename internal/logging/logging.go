@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Setup настраивает slog по умолчанию: level — debug, info (по умолчанию), warn или error;
+// format — text (по умолчанию) или json. Вызовы пакета log тоже идут через slog с уровнем info.
+func Setup(level, format string) error {
+	return setup(os.Stderr, level, format)
+}
+
+func setup(w io.Writer, level, format string) error {
+	var lvl slog.Level
+	if level == "" {
+		level = "info"
+	}
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("некорректный уровень логирования %s, ожидается debug, info, warn или error", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl, ReplaceAttr: redactAttr}
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		h = slog.NewTextHandler(w, opts)
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("некорректный формат логов %s, ожидается text или json", format)
+	}
+	slog.SetDefault(slog.New(contextHandler{h}))
+	return nil
+}
+
+// Fatal пишет ошибку и завершает процесс, как log.Fatal.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// contextHandler скрывает секреты в тексте сообщения и добавляет к записи ID запроса
+// из контекста. Атрибуты обрабатывает redactAttr, а текст сообщения, в том числе строки
+// пакета log, в ReplaceAttr не попадает.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.Message = Redact(r.Message)
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
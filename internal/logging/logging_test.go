@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	var buf bytes.Buffer
+	if err := setup(&buf, "debug", "text"); err != nil {
+		t.Fatal(err)
+	}
+	AddSecret("bot123456:secret-token")
+	AddAccountID("2000123456")
+
+	slog.Info("запрос к https://api.telegram.org/botbot123456:secret-token/getUpdates")
+	log.Printf("ошибка HTTP-клиента: bot123456:secret-token")
+	slog.Warn("ошибка", "error", errors.New("счёт 2000123456 не найден"), "api_token", "anything")
+	slog.With("url", "https://x/bot123456:secret-token").Info("с атрибутом")
+
+	out := buf.String()
+	if strings.Contains(out, "secret-token") || strings.Contains(out, "anything") {
+		t.Errorf("secret leaked into log:\n%s", out)
+	}
+	if strings.Contains(out, "2000123456") || !strings.Contains(out, "****3456") {
+		t.Errorf("account ID is not masked:\n%s", out)
+	}
+}
+
+func TestRequestIDHandler(t *testing.T) {
+	var buf bytes.Buffer
+	if err := setup(&buf, "debug", "text"); err != nil {
+		t.Fatal(err)
+	}
+	h := RequestIDHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("ResponseWriter does not implement http.Flusher")
+		}
+		if RequestID(r.Context()) != "abc-1" {
+			t.Errorf("request ID in context = %q", RequestID(r.Context()))
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/figi/x", nil)
+	req.Header.Set(RequestIDHeader, "abc-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Header().Get(RequestIDHeader) != "abc-1" {
+		t.Errorf("response request ID = %q", rec.Header().Get(RequestIDHeader))
+	}
+	if out := buf.String(); !strings.Contains(out, "level=WARN") || !strings.Contains(out, "status=404") || !strings.Contains(out, "request_id=abc-1") {
+		t.Errorf("request log = %s", out)
+	}
+}
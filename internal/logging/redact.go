@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys — атрибуты, значение которых не пишется в лог целиком.
+var sensitiveKeys = []string{"token", "password", "secret", "authorization"}
+
+var (
+	secretsMu sync.RWMutex
+	// secrets заменяются в любом тексте лога: сообщениях, ошибках, атрибутах.
+	secrets = map[string]string{}
+)
+
+// AddSecret скрывает value во всех последующих записях лога, например токен API.
+func AddSecret(value string) {
+	addReplacement(value, redacted)
+}
+
+// AddAccountID заменяет ID счёта в логах на маску с последними четырьмя символами,
+// чтобы записи разных счетов можно было различить.
+func AddAccountID(id string) {
+	addReplacement(id, MaskAccountID(id))
+}
+
+// MaskAccountID оставляет от ID счёта только последние четыре символа.
+func MaskAccountID(id string) string {
+	if len(id) <= 4 {
+		return "****"
+	}
+	return "****" + id[len(id)-4:]
+}
+
+func addReplacement(value, with string) {
+	if len(value) < 4 {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets[value] = with
+}
+
+// Redact заменяет в s известные секреты.
+func Redact(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for secret, with := range secrets {
+		s = strings.ReplaceAll(s, secret, with)
+	}
+	return s
+}
+
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	if key == "account_id" {
+		return slog.String(a.Key, MaskAccountID(a.Value.String()))
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+	}
+	return a
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+
+	"github.com/felixge/httpsnoop"
+)
+
+// RequestIDHeader — заголовок, из которого берётся и в который возвращается ID запроса.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID возвращает контекст с ID запроса.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID возвращает ID запроса из контекста или пустую строку.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID генерирует случайный ID из 16 hex-символов.
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RequestIDHandler берёт ID из X-Request-ID или генерирует новый, кладёт его в контекст
+// запроса и заголовок ответа и пишет в лог завершение запроса.
+func RequestIDHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		ctx := WithRequestID(r.Context(), id)
+
+		// Код ответа снимается той же обёрткой, что и в metrics.InstrumentHandler.
+		m := httpsnoop.CaptureMetrics(next, w, r.WithContext(ctx))
+
+		level := slog.LevelDebug
+		switch {
+		case m.Code >= 500:
+			level = slog.LevelError
+		case m.Code >= 400:
+			level = slog.LevelWarn
+		}
+		slog.Log(ctx, level, "HTTP-запрос",
+			"method", r.Method, "path", r.URL.Path, "status", m.Code, "duration", m.Duration)
+	})
+}
+
+// validRequestID ограничивает принятый от клиента ID, чтобы в лог не попал произвольный текст.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
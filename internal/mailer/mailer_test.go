@@ -0,0 +1,120 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listen запускает SMTP-заглушку на локальном порту и возвращает настройки для неё.
+func listen(t *testing.T, serve func(conn net.Conn)) Config {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				serve(conn)
+			}()
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return Config{Host: "127.0.0.1", Port: addr.Port, From: "report@example.com"}
+}
+
+func TestSendHTML(t *testing.T) {
+	received := make(chan string, 1)
+	cfg := listen(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 stub ESMTP")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 stub")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				received <- data.String()
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 unknown")
+			}
+		}
+	})
+
+	m := New(cfg)
+	if err := m.SendHTML(context.Background(), "user@example.com", "Отчёт", "<p>ok</p>"); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-received
+	if !strings.Contains(msg, "To: user@example.com") || !strings.Contains(msg, "<p>ok</p>") {
+		t.Errorf("message = %q", msg)
+	}
+}
+
+func TestSendHTMLStalledServer(t *testing.T) {
+	// Сервер принимает соединение и молчит: отправка должна прерваться по отмене ctx.
+	cfg := listen(t, func(conn net.Conn) {
+		time.Sleep(5 * time.Second)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := New(cfg).SendHTML(ctx, "user@example.com", "Отчёт", "<p>ok</p>")
+	if err == nil {
+		t.Fatal("SendHTML to a stalled server succeeded")
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("SendHTML returned after %v, want it to stop at the ctx deadline", time.Since(start))
+	}
+}
+
+func TestSendHTMLCanceled(t *testing.T) {
+	cfg := listen(t, func(conn net.Conn) {
+		time.Sleep(5 * time.Second)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	err := New(cfg).SendHTML(ctx, "user@example.com", "Отчёт", "<p>ok</p>")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+}
+
+func TestNewDefaultPort(t *testing.T) {
+	if got := New(Config{Host: "smtp.example.com"}).cfg.Port; got != 587 {
+		t.Errorf("port = %d, want 587", got)
+	}
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/felixge/httpsnoop"
+)
+
+// InstrumentHandler считает запросы к next по зарегистрированному в mux шаблону маршрута,
+// а не по пути, чтобы число рядов не зависело от ID в URL. Код ответа снимается через
+// httpsnoop: обёртка сохраняет http.Flusher и другие интерфейсы исходного ResponseWriter.
+func InstrumentHandler(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		method := r.Method
+		switch method {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			method = "other"
+		}
+
+		m := httpsnoop.CaptureMetrics(next, w, r)
+		HTTPRequests.WithLabelValues(route, method, strconv.Itoa(m.Code)).Inc()
+		HTTPDuration.WithLabelValues(route, method).Observe(m.Duration.Seconds())
+	})
+}
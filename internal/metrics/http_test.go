@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInstrumentHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/items/", func(w http.ResponseWriter, r *http.Request) {
+		// Обёртка не должна прятать Flusher: на нём держатся потоковые ответы.
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("ResponseWriter does not implement http.Flusher")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	h := InstrumentHandler(mux, mux)
+	for _, path := range []string{"/items/1", "/items/2"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	want := `tinvest_http_requests_total{code="201",method="POST",route="/items/"} 2`
+	if !strings.Contains(string(body), want) {
+		t.Errorf("metrics output does not contain %s", want)
+	}
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// JobBuckets — границы гистограммы длительности фоновых задач в секундах.
+var JobBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600}
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tinvest_http_requests_total",
+		Help: "HTTP-запросы по маршруту, методу и коду ответа",
+	}, []string{"route", "method", "code"})
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tinvest_http_request_duration_seconds",
+		Help:    "Длительность HTTP-запросов",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	TinkoffRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tinvest_tinkoff_requests_total",
+		Help: "Вызовы Tinkoff Invest API по методу и gRPC-коду",
+	}, []string{"method", "code"})
+	TinkoffDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tinvest_tinkoff_request_duration_seconds",
+		Help:    "Длительность вызовов Tinkoff Invest API",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+	TinkoffRateLimit = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tinvest_tinkoff_ratelimit_limit",
+		Help: "Лимит запросов в минуту из заголовка x-ratelimit-limit последнего ответа",
+	}, []string{"method"})
+	TinkoffRateLimitRemaining = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tinvest_tinkoff_ratelimit_remaining",
+		Help: "Остаток лимита запросов из заголовка x-ratelimit-remaining последнего ответа",
+	}, []string{"method"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tinvest_db_query_duration_seconds",
+		Help:    "Длительность запросов к Postgres по типу запроса",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+	DBQueryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tinvest_db_query_errors_total",
+		Help: "Ошибки запросов к Postgres по типу запроса",
+	}, []string{"operation"})
+
+	JobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tinvest_job_runs_total",
+		Help: "Запуски фоновых задач по результату: success, failed, lost, skipped",
+	}, []string{"job", "outcome"})
+	JobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tinvest_job_duration_seconds",
+		Help:    "Длительность фоновых задач",
+		Buckets: JobBuckets,
+	}, []string{"job"})
+
+	PortfolioValue = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tinvest_portfolio_value_rub",
+		Help: "Стоимость бумаг на счёте по последнему расчёту отчёта",
+	}, []string{"account"})
+	NetProfit = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tinvest_net_profit_rub",
+		Help: "Чистая прибыль по бумагам по последнему расчёту отчёта",
+	}, []string{"account"})
+)
+
+// Handler отдаёт метрики для GET /metrics, включая метрики Go-рантайма и процесса.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы правил оповещений.
+const (
+	// AlertPriceCross — цена инструмента пересекла порог.
+	AlertPriceCross = "price_cross"
+	// AlertDailyDrop — стоимость портфеля за сутки упала на threshold процентов или больше.
+	AlertDailyDrop = "daily_drop"
+	// AlertNetProfitCross — чистая прибыль пересекла порог.
+	AlertNetProfitCross = "net_profit_cross"
+)
+
+const (
+	AlertAbove = "above"
+	AlertBelow = "below"
+)
+
+const (
+	AlertDeliverySent   = "sent"
+	AlertDeliveryFailed = "failed"
+)
+
+// AlertRule — правило оповещения. Оповещение отправляется один раз при переходе
+// условия в выполненное состояние (active) и повторяется только после его сброса.
+// Если оповещение не удалось доставить, active сбрасывается и оно отправляется снова.
+// WebhookURL целиком виден только роли admin, остальным — схема и хост.
+type AlertRule struct {
+	ID              int        `db:"id" json:"id"`
+	Type            string     `db:"rule_type" json:"rule_type"`
+	FIGI            string     `db:"figi" json:"figi,omitempty"`
+	Direction       string     `db:"direction" json:"direction,omitempty"`
+	Threshold       float64    `db:"threshold" json:"threshold"`
+	WebhookURL      string     `db:"webhook_url" json:"webhook_url"`
+	Enabled         bool       `db:"enabled" json:"enabled"`
+	Comment         string     `db:"comment" json:"comment"`
+	Active          bool       `db:"active" json:"active"`
+	LastValue       *float64   `db:"last_value" json:"last_value"`
+	LastTriggeredAt *time.Time `db:"last_triggered_at" json:"last_triggered_at"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// AlertNotification — тело запроса к webhook.
+type AlertNotification struct {
+	RuleID      int       `json:"rule_id"`
+	RuleType    string    `json:"rule_type"`
+	FIGI        string    `json:"figi,omitempty"`
+	Direction   string    `json:"direction,omitempty"`
+	Threshold   float64   `json:"threshold"`
+	Value       float64   `json:"value"`
+	Message     string    `json:"message"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+// AlertDelivery — запись журнала доставки оповещения. WebhookURL, как и в правиле,
+// целиком виден только роли admin.
+type AlertDelivery struct {
+	ID           int             `db:"id" json:"id"`
+	RuleID       int             `db:"rule_id" json:"rule_id"`
+	WebhookURL   string          `db:"webhook_url" json:"webhook_url"`
+	Payload      json.RawMessage `db:"payload" json:"payload" swaggertype:"object"`
+	Status       string          `db:"status" json:"status"`
+	Attempts     int             `db:"attempts" json:"attempts"`
+	ResponseCode *int            `db:"response_code" json:"response_code"`
+	Error        string          `db:"error" json:"error"`
+	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
+}
+
+// AlertConfig — параметры проверки и доставки оповещений.
+type AlertConfig struct {
+	// WebhookURL — адрес по умолчанию для правил без собственного webhook_url.
+	WebhookURL  string        `json:"webhook_url"`
+	MaxAttempts int           `json:"max_attempts"`
+	RetryDelay  time.Duration `json:"retry_delay"`
+	// PriceMaxAge — сколько кэшированная цена считается актуальной.
+	PriceMaxAge time.Duration `json:"price_max_age"`
+}
@@ -0,0 +1,39 @@
+package models
+
+// Allocation — структура портфеля: доли позиций и группировки по типу, сектору, валюте, стране
+// и меткам. Операции с несколькими метками входят в каждую из них, поэтому доли by_tag
+// в сумме могут превышать 1. Позиции не в валюте отчёта не входят в total и доли,
+// они перечислены в excluded, а incomplete показывает, что такие позиции есть.
+type Allocation struct {
+	Total      float64              `json:"total"`
+	Cash       float64              `json:"cash"`
+	Positions  []AllocationPosition `json:"positions"`
+	ByType     []AllocationSlice    `json:"by_type"`
+	BySector   []AllocationSlice    `json:"by_sector"`
+	ByCurrency []AllocationSlice    `json:"by_currency"`
+	ByCountry  []AllocationSlice    `json:"by_country"`
+	ByTag      []AllocationSlice    `json:"by_tag,omitempty"`
+	Excluded   []AllocationPosition `json:"excluded,omitempty"`
+	Incomplete bool                 `json:"incomplete"`
+}
+
+type AllocationPosition struct {
+	FIGI     string  `json:"figi"`
+	Ticker   string  `json:"ticker"`
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Sector   string  `json:"sector"`
+	Currency string  `json:"currency"`
+	Country  string  `json:"country"`
+	Quantity float64 `json:"quantity"`
+	Price    float64 `json:"price"`
+	Value    float64 `json:"value"`
+	Weight   float64 `json:"weight"`
+}
+
+// AllocationSlice — суммарная стоимость и доля одной группы.
+type AllocationSlice struct {
+	Key    string  `json:"key"`
+	Value  float64 `json:"value"`
+	Weight float64 `json:"weight"`
+}
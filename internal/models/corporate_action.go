@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+const (
+	CorporateActionSplit      = "split"
+	CorporateActionConversion = "conversion"
+	CorporateActionDelisting  = "delisting"
+
+	CorporateActionSourceManual = "manual"
+)
+
+// CorporateAction — сплит, конвертация или делистинг инструмента.
+// Ratio — количество новых бумаг на одну старую (сплит 1:10 — 10, обратный сплит 10:1 — 0.1).
+// Для конвертации бумаги FIGI превращаются в NewFIGI.
+type CorporateAction struct {
+	ID            int       `db:"id" json:"id"`
+	Type          string    `db:"action_type" json:"type"`
+	FIGI          string    `db:"figi" json:"figi"`
+	NewFIGI       string    `db:"new_figi" json:"new_figi,omitempty"`
+	Ratio         float64   `db:"ratio" json:"ratio"`
+	EffectiveDate time.Time `db:"effective_date" json:"effective_date"`
+	Source        string    `db:"source" json:"source"`
+	Comment       string    `db:"comment" json:"comment"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+// CorporateActionCandidate — расхождение между количеством бумаг по операциям и у брокера.
+// SuggestedType равен split, если коэффициент похож на сплит; действие всё равно нужно
+// подтвердить вручную — причиной может быть и пропущенная операция.
+type CorporateActionCandidate struct {
+	FIGI             string  `json:"figi"`
+	ExpectedQuantity float64 `json:"expected_quantity"`
+	ActualQuantity   float64 `json:"actual_quantity"`
+	Ratio            float64 `json:"ratio,omitempty"`
+	SuggestedType    string  `json:"suggested_type,omitempty"`
+}
+
+// InstrumentPrice — последняя известная цена инструмента и цена, назначенная вручную.
+// Используется для делистингованных и замороженных бумаг, по которым API не отдаёт цену.
+type InstrumentPrice struct {
+	FIGI          string     `db:"figi" json:"figi"`
+	Name          string     `db:"name" json:"name"`
+	LastPrice     *float64   `db:"last_price" json:"last_price"`
+	LastPriceAt   *time.Time `db:"last_price_at" json:"last_price_at"`
+	ManualPrice   *float64   `db:"manual_price" json:"manual_price"`
+	ManualPriceAt *time.Time `db:"manual_price_at" json:"manual_price_at"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestSubscription — подписка на письмо с отчётом. Письмо уходит в send_hour по времени
+// сервера каждый день или, для weekly, в день недели weekday (0 — воскресенье).
+type DigestSubscription struct {
+	ID         int        `db:"id" json:"id"`
+	Email      string     `db:"email" json:"email"`
+	Frequency  string     `db:"frequency" json:"frequency"`
+	SendHour   int        `db:"send_hour" json:"send_hour"`
+	Weekday    int        `db:"weekday" json:"weekday"`
+	Enabled    bool       `db:"enabled" json:"enabled"`
+	LastSentAt *time.Time `db:"last_sent_at" json:"last_sent_at"`
+	LastError  string     `db:"last_error" json:"last_error"`
+	// FailedAttempts — неудачные попытки отправки по расписанию подряд, LastAttemptAt — время последней.
+	FailedAttempts int        `db:"failed_attempts" json:"failed_attempts"`
+	LastAttemptAt  *time.Time `db:"last_attempt_at" json:"last_attempt_at"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// PriceMover — изменение цены инструмента за период дайджеста.
+type PriceMover struct {
+	FIGI      string  `json:"figi"`
+	Name      string  `json:"name"`
+	FromPrice float64 `json:"from_price"`
+	Price     float64 `json:"price"`
+	Change    float64 `json:"change"` // в процентах
+}
+
+// IncomeItem — полученные дивиденды или купон.
+type IncomeItem struct {
+	Date   time.Time `json:"date"`
+	FIGI   string    `json:"figi"`
+	Name   string    `json:"name"`
+	Type   string    `json:"type"`
+	Amount float64   `json:"amount"`
+}
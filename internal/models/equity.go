@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// EquityPoint — состояние счёта на конец дня.
+// NetDeposits — накопленные заводы минус выводы, Profit = Equity - NetDeposits.
+// TWRIndex — доходность, очищенная от заводов и выводов (time-weighted, старт = 1),
+// Drawdown — просадка TWRIndex от предыдущего максимума (0 или отрицательное число).
+type EquityPoint struct {
+	AccountID   string    `db:"account_id" json:"account_id"`
+	Date        time.Time `db:"date" json:"date"`
+	Cash        float64   `db:"cash" json:"cash"`
+	MarketValue float64   `db:"market_value" json:"market_value"`
+	Equity      float64   `db:"equity" json:"equity"`
+	NetDeposits float64   `db:"net_deposits" json:"net_deposits"`
+	Profit      float64   `db:"profit" json:"profit"`
+	TWRIndex    float64   `db:"twr_index" json:"twr_index"`
+	Drawdown    float64   `db:"drawdown" json:"drawdown"`
+}
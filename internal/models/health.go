@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+)
+
+// DependencyStatus — результат проверки одной зависимости.
+type DependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Detail    string `json:"detail,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Readiness — ответ /readyz: общий статус, проверки зависимостей и время последних
+// успешных загрузки операций и автосохранения.
+type Readiness struct {
+	Status       string                      `json:"status"`
+	Checks       map[string]DependencyStatus `json:"checks"`
+	LastSync     *time.Time                  `json:"last_sync,omitempty"`
+	LastAutosave *time.Time                  `json:"last_autosave,omitempty"`
+	CheckedAt    time.Time                   `json:"checked_at"`
+}
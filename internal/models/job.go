@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Статусы запуска фоновой задачи.
+const (
+	JobRunRunning = "running"
+	JobRunSuccess = "success"
+	JobRunFailed  = "failed"
+	// JobRunLost — реплика потеряла блокировку или завершилась, не дождавшись конца задачи.
+	JobRunLost = "lost"
+)
+
+// JobRun — запись истории запусков задачи из таблицы job_runs.
+type JobRun struct {
+	ID          int64      `json:"id"`
+	JobName     string     `json:"job_name"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	Manual      bool       `json:"manual"`
+	Instance    string     `json:"instance"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
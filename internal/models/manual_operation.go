@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ManualOperation — операция, внесённая вручную: OTC-переводы, подарки акций,
+// стоимость покупки у предыдущего брокера и прочие события, которых нет в API.
+type ManualOperation struct {
+	ID            int       `db:"id" json:"id"`
+	Currency      string    `db:"currency" json:"currency"`
+	FloatPayment  float64   `db:"payment" json:"float_payment"`
+	Date          time.Time `db:"operation_date" json:"date"`
+	Type          string    `db:"type" json:"type"`
+	OperationType string    `db:"operation_type" json:"operation_type"`
+	FIGI          string    `db:"figi" json:"figi"`
+	Quantity      float64   `db:"quantity" json:"quantity"`
+	Price         float64   `db:"price" json:"price"`
+	Comment       string    `db:"comment" json:"comment"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ManualOperationAudit — запись журнала изменений ручной операции. ChangedBy — имя пользователя,
+// ключом которого выполнено изменение.
+type ManualOperationAudit struct {
+	ID          int             `db:"id" json:"id"`
+	OperationID int             `db:"operation_id" json:"operation_id"`
+	Action      string          `db:"action" json:"action"`
+	OldData     json.RawMessage `db:"old_data" json:"old_data,omitempty" swaggertype:"object"`
+	NewData     json.RawMessage `db:"new_data" json:"new_data,omitempty" swaggertype:"object"`
+	ChangedAt   time.Time       `db:"changed_at" json:"changed_at"`
+	ChangedBy   string          `db:"changed_by" json:"changed_by"`
+}
@@ -3,16 +3,18 @@ package models
 import "time"
 
 type Operation struct {
-	ID            string  `json:"id"`
-	Currency      string  `json:"currency"`
-	FloatPayment  float64 `json:"float_payment"`
-	Date          string  `json:"date"`
-	Type          string  `json:"type"`
-	OperationType string  `json:"operation_type"`
-	FIGI          string  `json:"figi"`
-	Quantity      float64 `json:"quantity"`
-	Price         float64 `json:"price"`
-	IsCanceled    bool    `json:"is_canceled"`
+	ID            string   `json:"id"`
+	Currency      string   `json:"currency"`
+	FloatPayment  float64  `json:"float_payment"`
+	Date          string   `json:"date"`
+	Type          string   `json:"type"`
+	OperationType string   `json:"operation_type"`
+	FIGI          string   `json:"figi"`
+	Quantity      float64  `json:"quantity"`
+	Price         float64  `json:"price"`
+	IsCanceled    bool     `json:"is_canceled"`
+	IsManual      bool     `json:"is_manual"`
+	Tags          []string `json:"tags,omitempty"`
 }
 
 type PriceResponse struct {
@@ -20,16 +22,37 @@ type PriceResponse struct {
 	Price float64 `json:"price"`
 }
 
+const (
+	SummaryTriggerManual = "manual"
+	SummaryTriggerAuto   = "auto"
+)
+
 type Summary struct {
-	ID             int       `db:"id" json:"id"`
-	TotalInput     float64   `db:"total_input" json:"total_input"`
-	TotalOutput    float64   `db:"total_output" json:"total_output"`
-	Turnover       float64   `db:"turnover" json:"turnover"`
-	TotalBuys      float64   `db:"total_buys" json:"total_buys"`
-	TotalSells     float64   `db:"total_sells" json:"total_sells"`
-	PortfolioValue float64   `db:"portfolio_value" json:"portfolio_value"`
-	Commissions    float64   `db:"commissions" json:"commissions"`
-	Taxes          float64   `db:"taxes" json:"taxes"`
-	NetStockProfit float64   `db:"net_stock_profit" json:"net_stock_profit"`
-	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	ID             int                 `db:"id" json:"id"`
+	TotalInput     float64             `db:"total_input" json:"total_input"`
+	TotalOutput    float64             `db:"total_output" json:"total_output"`
+	Turnover       float64             `db:"turnover" json:"turnover"`
+	TotalBuys      float64             `db:"total_buys" json:"total_buys"`
+	TotalSells     float64             `db:"total_sells" json:"total_sells"`
+	PortfolioValue float64             `db:"portfolio_value" json:"portfolio_value"`
+	Commissions    float64             `db:"commissions" json:"commissions"`
+	Taxes          float64             `db:"taxes" json:"taxes"`
+	NetStockProfit float64             `db:"net_stock_profit" json:"net_stock_profit"`
+	AccountID      string              `db:"account_id" json:"account_id"`
+	PeriodFrom     *time.Time          `db:"period_from" json:"period_from"`
+	PeriodTo       *time.Time          `db:"period_to" json:"period_to"`
+	Currency       string              `db:"currency" json:"currency"`
+	Trigger        string              `db:"trigger" json:"trigger"`
+	AppVersion     string              `db:"app_version" json:"app_version"`
+	Instruments    []InstrumentSummary `db:"payload" json:"instruments"`
+	CreatedAt      time.Time           `db:"created_at" json:"created_at"`
+}
+
+// InstrumentSummary — вклад одного инструмента в стоимость портфеля.
+type InstrumentSummary struct {
+	FIGI     string  `json:"figi"`
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity"`
+	Price    float64 `json:"price"`
+	Value    float64 `json:"value"`
 }
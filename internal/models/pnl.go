@@ -0,0 +1,17 @@
+package models
+
+// InstrumentPnL — результат по одному инструменту: покупки, продажи, комиссии и налоги
+// по его операциям и текущая стоимость открытой позиции.
+type InstrumentPnL struct {
+	FIGI        string  `json:"figi"`
+	Ticker      string  `json:"ticker"`
+	Name        string  `json:"name"`
+	Quantity    float64 `json:"quantity"`
+	Price       float64 `json:"price"`
+	Value       float64 `json:"value"`
+	TotalBuys   float64 `json:"total_buys"`
+	TotalSells  float64 `json:"total_sells"`
+	Commissions float64 `json:"commissions"`
+	Taxes       float64 `json:"taxes"`
+	NetProfit   float64 `json:"net_profit"`
+}
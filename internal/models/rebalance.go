@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Измерения, по которым задаются целевые доли: отдельные инструменты или группы из /allocation.
+const (
+	TargetByFIGI     = "figi"
+	TargetByType     = "type"
+	TargetBySector   = "sector"
+	TargetByCurrency = "currency"
+	TargetByCountry  = "country"
+)
+
+// AllocationTarget — целевая доля инструмента или группы. Остаток до 1 считается целевой долей денег.
+type AllocationTarget struct {
+	Dimension string    `db:"dimension" json:"dimension"`
+	Key       string    `db:"key" json:"key"`
+	Weight    float64   `db:"weight" json:"weight"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// RebalancePlan — сделки, приводящие портфель к целевым долям. Excluded — инструменты
+// не в валюте отчёта: их цены нельзя сравнивать с рублёвыми, сделки по ним не считаются.
+type RebalancePlan struct {
+	Dimension   string           `json:"dimension"`
+	Total       float64          `json:"total"`
+	CashBefore  float64          `json:"cash_before"`
+	CashAfter   float64          `json:"cash_after"`
+	Trades      []RebalanceTrade `json:"trades"`
+	Unallocated []string         `json:"unallocated,omitempty"`
+	Excluded    []string         `json:"excluded,omitempty"`
+}
+
+type RebalanceTrade struct {
+	FIGI          string  `json:"figi"`
+	Ticker        string  `json:"ticker"`
+	Name          string  `json:"name"`
+	Action        string  `json:"action"`
+	Lots          int64   `json:"lots"`
+	Quantity      float64 `json:"quantity"`
+	Price         float64 `json:"price"`
+	Value         float64 `json:"value"`
+	CurrentWeight float64 `json:"current_weight"`
+	TargetWeight  float64 `json:"target_weight"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// RetentionPolicy — сколько хранить снимки с исходной частотой, по одному в день и по одному в месяц.
+// Снимки моложе HourlyDays дней не трогаются, до DailyMonths месяцев остаётся последний снимок дня,
+// дальше — последний снимок месяца.
+type RetentionPolicy struct {
+	HourlyDays  int  `json:"hourly_days"`
+	DailyMonths int  `json:"daily_months"`
+	DryRun      bool `json:"dry_run"`
+}
+
+// RetentionRun — результат одного прогона очистки снимков. Trigger — manual для запуска через API,
+// auto для задачи по расписанию; HourlyDays и DailyMonths — политика, с которой выполнен прогон.
+type RetentionRun struct {
+	ID           int              `db:"id" json:"id"`
+	RunAt        time.Time        `db:"run_at" json:"run_at"`
+	Trigger      string           `db:"trigger" json:"trigger"`
+	HourlyDays   int              `db:"hourly_days" json:"hourly_days"`
+	DailyMonths  int              `db:"daily_months" json:"daily_months"`
+	DryRun       bool             `db:"dry_run" json:"dry_run"`
+	HourlyCutoff time.Time        `db:"hourly_cutoff" json:"hourly_cutoff"`
+	DailyCutoff  time.Time        `db:"daily_cutoff" json:"daily_cutoff"`
+	PrunedCount  int              `db:"pruned_count" json:"pruned_count"`
+	Pruned       []PrunedSnapshot `db:"pruned" json:"pruned"`
+}
+
+// PrunedSnapshot — удалённый (или подлежащий удалению при dry run) снимок.
+type PrunedSnapshot struct {
+	ID        int       `json:"id"`
+	AccountID string    `json:"account_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Tier      string    `json:"tier"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// RiskConfig — параметры расчёта риск-метрик по умолчанию.
+type RiskConfig struct {
+	RiskFreeRate  float64 `json:"risk_free_rate"`
+	BenchmarkFIGI string  `json:"benchmark_figi"`
+}
+
+// RiskMetrics — риск-метрики счёта за период по индексу доходности без учёта заводов и выводов.
+type RiskMetrics struct {
+	AccountID        string    `json:"account_id"`
+	From             time.Time `json:"from"`
+	To               time.Time `json:"to"`
+	Days             int       `json:"days"`
+	TotalReturn      float64   `json:"total_return"`
+	AnnualizedReturn float64   `json:"annualized_return"`
+	Volatility       float64   `json:"volatility"`
+	MaxDrawdown      float64   `json:"max_drawdown"`
+	MaxDrawdownDays  int       `json:"max_drawdown_days"`
+	Sharpe           float64   `json:"sharpe"`
+	Sortino          float64   `json:"sortino"`
+	RiskFreeRate     float64   `json:"risk_free_rate"`
+	BenchmarkFIGI    string    `json:"benchmark_figi,omitempty"`
+	Beta             *float64  `json:"beta,omitempty"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	TransferIn  = "in"
+	TransferOut = "out"
+)
+
+// SecurityTransferBasis — данные, которых нет в API для перевода бумаг между брокерами.
+// Для ввода бумаг Price — цена приобретения у предыдущего брокера, AcquiredAt — дата покупки.
+// Для вывода Price — оценка бумаг на дату вывода; без неё используется стоимость по FIFO.
+type SecurityTransferBasis struct {
+	OperationID string     `db:"operation_id" json:"operation_id"`
+	Price       float64    `db:"price" json:"price"`
+	AcquiredAt  *time.Time `db:"acquired_at" json:"acquired_at,omitempty"`
+	Comment     string     `db:"comment" json:"comment"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// SecurityTransfer — операция ввода или вывода бумаг вместе с указанной стоимостью.
+type SecurityTransfer struct {
+	OperationID string                 `json:"operation_id"`
+	Direction   string                 `json:"direction"`
+	FIGI        string                 `json:"figi"`
+	Quantity    float64                `json:"quantity"`
+	Date        time.Time              `json:"date"`
+	Basis       *SecurityTransferBasis `json:"basis"`
+}
+
+// Lot — открытая часть позиции, оставшаяся после списания продаж по FIFO.
+type Lot struct {
+	FIGI        string    `json:"figi"`
+	Quantity    float64   `json:"quantity"`
+	Price       float64   `json:"price"`
+	AcquiredAt  time.Time `json:"acquired_at"`
+	LDVEligible bool      `json:"ldv_eligible"`
+}
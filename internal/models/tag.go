@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// UntaggedGroup — группа отчёта для операций без меток, имя зарезервировано.
+const UntaggedGroup = "untagged"
+
+// Tag — пользовательская метка стратегии. Метки назначаются инструментам и отдельным
+// операциям; метки операции заменяют метки её инструмента.
+type Tag struct {
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// TagAssignments — назначенные метки: по FIGI и по ID операции.
+type TagAssignments struct {
+	Instruments map[string][]string `json:"instruments"`
+	Operations  map[string][]string `json:"operations"`
+}
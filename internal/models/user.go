@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Роли пользователей API: viewer только читает, admin также изменяет данные и управляет пользователями.
+const (
+	RoleViewer = "viewer"
+	RoleAdmin  = "admin"
+)
+
+// User — пользователь API из таблицы users. Ключ хранится только в виде хэша,
+// KeyPrefix — его начало, чтобы отличать ключи в списке.
+type User struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role"`
+	KeyPrefix  string     `json:"key_prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// UserKey — пользователь вместе с выпущенным ключом. Ключ показывается один раз
+// при создании пользователя или перевыпуске, восстановить его нельзя.
+type UserKey struct {
+	User User   `json:"user"`
+	Key  string `json:"key"`
+}
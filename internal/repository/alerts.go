@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+)
+
+const alertRuleColumns = `
+	id, rule_type, figi, direction, threshold, webhook_url, enabled, comment,
+	active, last_value, last_triggered_at, created_at, updated_at`
+
+func scanAlertRule(row pgx.Row) (models.AlertRule, error) {
+	var a models.AlertRule
+	err := row.Scan(
+		&a.ID, &a.Type, &a.FIGI, &a.Direction, &a.Threshold, &a.WebhookURL, &a.Enabled, &a.Comment,
+		&a.Active, &a.LastValue, &a.LastTriggeredAt, &a.CreatedAt, &a.UpdatedAt,
+	)
+	return a, err
+}
+
+// ListAlertRules возвращает правила; при onlyEnabled — только включённые.
+func (r *Repository) ListAlertRules(ctx context.Context, onlyEnabled bool) ([]models.AlertRule, error) {
+	rows, err := r.DB.Query(ctx, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE enabled OR NOT $1 ORDER BY id`, onlyEnabled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.AlertRule
+	for rows.Next() {
+		a, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, a)
+	}
+	return rules, rows.Err()
+}
+
+func (r *Repository) GetAlertRule(ctx context.Context, id int) (models.AlertRule, error) {
+	return scanAlertRule(r.DB.QueryRow(ctx, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE id = $1`, id))
+}
+
+func (r *Repository) CreateAlertRule(ctx context.Context, a models.AlertRule) (models.AlertRule, error) {
+	row := r.DB.QueryRow(ctx, `
+	INSERT INTO alert_rules (rule_type, figi, direction, threshold, webhook_url, enabled, comment)
+	VALUES ($1,$2,$3,$4,$5,$6,$7)
+	RETURNING `+alertRuleColumns,
+		a.Type, a.FIGI, a.Direction, a.Threshold, a.WebhookURL, a.Enabled, a.Comment,
+	)
+	return scanAlertRule(row)
+}
+
+// UpdateAlertRule изменяет условие правила и сбрасывает его состояние, чтобы новое
+// условие проверялось с нуля. Возвращает pgx.ErrNoRows, если правила нет.
+func (r *Repository) UpdateAlertRule(ctx context.Context, id int, a models.AlertRule) (models.AlertRule, error) {
+	row := r.DB.QueryRow(ctx, `
+	UPDATE alert_rules SET
+		rule_type = $2, figi = $3, direction = $4, threshold = $5, webhook_url = $6,
+		enabled = $7, comment = $8, active = false, last_value = NULL, updated_at = now()
+	WHERE id = $1
+	RETURNING `+alertRuleColumns,
+		id, a.Type, a.FIGI, a.Direction, a.Threshold, a.WebhookURL, a.Enabled, a.Comment,
+	)
+	return scanAlertRule(row)
+}
+
+// DeleteAlertRule возвращает pgx.ErrNoRows, если правила нет.
+func (r *Repository) DeleteAlertRule(ctx context.Context, id int) error {
+	tag, err := r.DB.Exec(ctx, `DELETE FROM alert_rules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// SaveAlertState сохраняет результат проверки правила; triggeredAt задаётся при срабатывании.
+func (r *Repository) SaveAlertState(ctx context.Context, id int, active bool, value float64, triggeredAt *time.Time) error {
+	_, err := r.DB.Exec(ctx, `
+	UPDATE alert_rules SET
+		active = $2, last_value = $3, last_triggered_at = COALESCE($4, last_triggered_at)
+	WHERE id = $1`,
+		id, active, value, triggeredAt,
+	)
+	return err
+}
+
+// ResetAlertState снимает признак active, чтобы правило сработало при следующей проверке.
+func (r *Repository) ResetAlertState(ctx context.Context, id int) error {
+	_, err := r.DB.Exec(ctx, `UPDATE alert_rules SET active = false WHERE id = $1`, id)
+	return err
+}
+
+func (r *Repository) SaveAlertDelivery(ctx context.Context, d models.AlertDelivery) error {
+	_, err := r.DB.Exec(ctx, `
+	INSERT INTO alert_deliveries (rule_id, webhook_url, payload, status, attempts, response_code, error)
+	VALUES ($1,$2,$3,$4,$5,$6,$7)`,
+		d.RuleID, d.WebhookURL, d.Payload, d.Status, d.Attempts, d.ResponseCode, d.Error,
+	)
+	return err
+}
+
+// GetAlertDeliveries возвращает последние доставки, начиная с новых; ruleID 0 — по всем правилам.
+func (r *Repository) GetAlertDeliveries(ctx context.Context, ruleID, limit int) ([]models.AlertDelivery, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT id, rule_id, webhook_url, payload, status, attempts, response_code, error, created_at
+		FROM alert_deliveries
+		WHERE $1 = 0 OR rule_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, ruleID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanAlertDelivery)
+}
+
+func scanAlertDelivery(row pgx.CollectableRow) (models.AlertDelivery, error) {
+	var d models.AlertDelivery
+	err := row.Scan(&d.ID, &d.RuleID, &d.WebhookURL, &d.Payload, &d.Status, &d.Attempts,
+		&d.ResponseCode, &d.Error, &d.CreatedAt)
+	return d, err
+}
+
+// GetAlertDeliveriesSince возвращает оповещения, отправленные начиная с since, в хронологическом порядке.
+func (r *Repository) GetAlertDeliveriesSince(ctx context.Context, since time.Time) ([]models.AlertDelivery, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT id, rule_id, webhook_url, payload, status, attempts, response_code, error, created_at
+		FROM alert_deliveries
+		WHERE created_at >= $1
+		ORDER BY created_at, id
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanAlertDelivery)
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+)
+
+// GetAllocationTargets возвращает целевые доли измерения; пустое измерение — все цели.
+func (r *Repository) GetAllocationTargets(ctx context.Context, dimension string) ([]models.AllocationTarget, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT dimension, key, weight, updated_at
+		FROM allocation_targets
+		WHERE $1 = '' OR dimension = $1
+		ORDER BY dimension, weight DESC, key
+	`, dimension)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []models.AllocationTarget
+	for rows.Next() {
+		var t models.AllocationTarget
+		if err := rows.Scan(&t.Dimension, &t.Key, &t.Weight, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	return targets, rows.Err()
+}
+
+// ReplaceAllocationTargets заменяет все целевые доли измерения.
+func (r *Repository) ReplaceAllocationTargets(ctx context.Context, dimension string, targets []models.AllocationTarget) error {
+	return pgx.BeginFunc(ctx, r.DB, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM allocation_targets WHERE dimension = $1`, dimension); err != nil {
+			return err
+		}
+		for _, t := range targets {
+			_, err := tx.Exec(ctx, `
+			INSERT INTO allocation_targets (dimension, key, weight, updated_at)
+			VALUES ($1, $2, $3, now())`,
+				dimension, t.Key, t.Weight,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+)
+
+const corporateActionColumns = `
+	id, action_type, figi, new_figi, ratio, effective_date, source, comment, created_at`
+
+func scanCorporateAction(row pgx.Row) (models.CorporateAction, error) {
+	var a models.CorporateAction
+	err := row.Scan(
+		&a.ID, &a.Type, &a.FIGI, &a.NewFIGI, &a.Ratio, &a.EffectiveDate,
+		&a.Source, &a.Comment, &a.CreatedAt,
+	)
+	return a, err
+}
+
+// ListCorporateActions возвращает корпоративные действия в порядке их вступления в силу.
+func (r *Repository) ListCorporateActions(ctx context.Context) ([]models.CorporateAction, error) {
+	rows, err := r.DB.Query(ctx, `SELECT `+corporateActionColumns+` FROM corporate_actions ORDER BY effective_date, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []models.CorporateAction
+	for rows.Next() {
+		a, err := scanCorporateAction(rows)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
+
+func (r *Repository) CreateCorporateAction(ctx context.Context, a models.CorporateAction) (models.CorporateAction, error) {
+	row := r.DB.QueryRow(ctx, `
+	INSERT INTO corporate_actions (
+		action_type, figi, new_figi, ratio, effective_date, source, comment
+	) VALUES ($1,$2,$3,$4,$5,$6,$7)
+	RETURNING `+corporateActionColumns,
+		a.Type, a.FIGI, a.NewFIGI, a.Ratio, a.EffectiveDate, a.Source, a.Comment,
+	)
+	return scanCorporateAction(row)
+}
+
+// DeleteCorporateAction возвращает pgx.ErrNoRows, если действия нет.
+func (r *Repository) DeleteCorporateAction(ctx context.Context, id int) error {
+	tag, err := r.DB.Exec(ctx, `DELETE FROM corporate_actions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+)
+
+const digestColumns = `
+	id, email, frequency, send_hour, weekday, enabled, last_sent_at, last_error,
+	failed_attempts, last_attempt_at, created_at, updated_at`
+
+func scanDigestSubscription(row pgx.Row) (models.DigestSubscription, error) {
+	var s models.DigestSubscription
+	err := row.Scan(
+		&s.ID, &s.Email, &s.Frequency, &s.SendHour, &s.Weekday, &s.Enabled,
+		&s.LastSentAt, &s.LastError, &s.FailedAttempts, &s.LastAttemptAt, &s.CreatedAt, &s.UpdatedAt,
+	)
+	return s, err
+}
+
+func (r *Repository) ListDigestSubscriptions(ctx context.Context) ([]models.DigestSubscription, error) {
+	rows, err := r.DB.Query(ctx, `SELECT `+digestColumns+` FROM digest_subscriptions ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []models.DigestSubscription
+	for rows.Next() {
+		s, err := scanDigestSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
+
+func (r *Repository) GetDigestSubscription(ctx context.Context, id int) (models.DigestSubscription, error) {
+	return scanDigestSubscription(r.DB.QueryRow(ctx, `SELECT `+digestColumns+` FROM digest_subscriptions WHERE id = $1`, id))
+}
+
+func (r *Repository) CreateDigestSubscription(ctx context.Context, s models.DigestSubscription) (models.DigestSubscription, error) {
+	row := r.DB.QueryRow(ctx, `
+	INSERT INTO digest_subscriptions (email, frequency, send_hour, weekday, enabled)
+	VALUES ($1,$2,$3,$4,$5)
+	RETURNING `+digestColumns,
+		s.Email, s.Frequency, s.SendHour, s.Weekday, s.Enabled,
+	)
+	return scanDigestSubscription(row)
+}
+
+// UpdateDigestSubscription возвращает pgx.ErrNoRows, если подписки нет.
+func (r *Repository) UpdateDigestSubscription(ctx context.Context, id int, s models.DigestSubscription) (models.DigestSubscription, error) {
+	row := r.DB.QueryRow(ctx, `
+	UPDATE digest_subscriptions SET
+		email = $2, frequency = $3, send_hour = $4, weekday = $5, enabled = $6, updated_at = now()
+	WHERE id = $1
+	RETURNING `+digestColumns,
+		id, s.Email, s.Frequency, s.SendHour, s.Weekday, s.Enabled,
+	)
+	return scanDigestSubscription(row)
+}
+
+// DeleteDigestSubscription возвращает pgx.ErrNoRows, если подписки нет.
+func (r *Repository) DeleteDigestSubscription(ctx context.Context, id int) error {
+	tag, err := r.DB.Exec(ctx, `DELETE FROM digest_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// SaveDigestResult записывает результат отправки вне расписания: при успехе — время отправки
+// и сброс счётчика неудач, иначе — ошибку. Счётчик попыток по расписанию ошибка не меняет.
+func (r *Repository) SaveDigestResult(ctx context.Context, id int, sentAt *time.Time, sendErr string) error {
+	_, err := r.DB.Exec(ctx, `
+	UPDATE digest_subscriptions SET
+		last_sent_at = COALESCE($2, last_sent_at), last_error = $3,
+		failed_attempts = CASE WHEN $2::timestamp IS NULL THEN failed_attempts ELSE 0 END
+	WHERE id = $1`,
+		id, sentAt, sendErr,
+	)
+	return err
+}
+
+// SaveDigestAttempt записывает попытку отправки по расписанию в момент at: при успехе
+// (пустой sendErr) — время отправки, иначе — ошибку; failedAttempts — неудачи подряд.
+func (r *Repository) SaveDigestAttempt(ctx context.Context, id int, at time.Time, sendErr string, failedAttempts int) error {
+	_, err := r.DB.Exec(ctx, `
+	UPDATE digest_subscriptions SET
+		last_attempt_at = $2,
+		last_sent_at = CASE WHEN $3 = '' THEN $2 ELSE last_sent_at END,
+		last_error = $3, failed_attempts = $4
+	WHERE id = $1`,
+		id, at, sendErr, failedAttempts,
+	)
+	return err
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tinvest_report/internal/models"
+)
+
+func TestSaveDigestAttempt(t *testing.T) {
+	r := newTestRepository(t, "digest_subscriptions")
+	ctx := context.Background()
+	sub, err := r.CreateDigestSubscription(ctx, models.DigestSubscription{
+		Email: "me@example.com", Frequency: models.DigestDaily, SendHour: 9, Enabled: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC)
+	if err := r.SaveDigestAttempt(ctx, sub.ID, first, "smtp: connection refused", 1); err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.GetDigestSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FailedAttempts != 1 || got.LastAttemptAt == nil || !got.LastAttemptAt.Equal(first) || got.LastSentAt != nil {
+		t.Errorf("after failure: %+v", got)
+	}
+
+	second := first.Add(10 * time.Minute)
+	if err := r.SaveDigestAttempt(ctx, sub.ID, second, "", 0); err != nil {
+		t.Fatal(err)
+	}
+	got, err = r.GetDigestSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FailedAttempts != 0 || got.LastError != "" || got.LastSentAt == nil || !got.LastSentAt.Equal(second) {
+		t.Errorf("after success: %+v", got)
+	}
+}
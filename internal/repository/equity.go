@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+)
+
+// ReplaceEquity перезаписывает ряд капитала счёта целиком.
+func (r *Repository) ReplaceEquity(ctx context.Context, accountID string, points []models.EquityPoint) error {
+	return pgx.BeginFunc(ctx, r.DB, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM equity_daily WHERE account_id = $1`, accountID); err != nil {
+			return err
+		}
+		_, err := tx.CopyFrom(ctx,
+			pgx.Identifier{"equity_daily"},
+			[]string{"account_id", "date", "cash", "market_value", "equity", "net_deposits", "profit", "twr_index", "drawdown"},
+			pgx.CopyFromSlice(len(points), func(i int) ([]any, error) {
+				p := points[i]
+				return []any{accountID, p.Date, p.Cash, p.MarketValue, p.Equity, p.NetDeposits, p.Profit, p.TWRIndex, p.Drawdown}, nil
+			}),
+		)
+		return err
+	})
+}
+
+// GetEquity возвращает ряд капитала за период; нулевые from/to не ограничивают период.
+func (r *Repository) GetEquity(ctx context.Context, accountID string, from, to time.Time) ([]models.EquityPoint, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT account_id, date, cash, market_value, equity, net_deposits, profit, twr_index, drawdown
+		FROM equity_daily
+		WHERE account_id = $1
+		  AND ($2::date IS NULL OR date >= $2)
+		  AND ($3::date IS NULL OR date <= $3)
+		ORDER BY date
+	`, accountID, nullTime(from), nullTime(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []models.EquityPoint
+	for rows.Next() {
+		var p models.EquityPoint
+		if err := rows.Scan(&p.AccountID, &p.Date, &p.Cash, &p.MarketValue, &p.Equity, &p.NetDeposits, &p.Profit, &p.TWRIndex, &p.Drawdown); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"tinvest_report/internal/models"
+)
+
+func (r *Repository) GetInstrumentPrices(ctx context.Context) (map[string]models.InstrumentPrice, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT figi, name, last_price, last_price_at, manual_price, manual_price_at
+		FROM instrument_prices
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := make(map[string]models.InstrumentPrice)
+	for rows.Next() {
+		var p models.InstrumentPrice
+		if err := rows.Scan(&p.FIGI, &p.Name, &p.LastPrice, &p.LastPriceAt, &p.ManualPrice, &p.ManualPriceAt); err != nil {
+			return nil, err
+		}
+		prices[p.FIGI] = p
+	}
+	return prices, rows.Err()
+}
+
+// SaveLastKnownPrices запоминает цены, полученные из API, одним запросом. Ручные цены не затрагиваются.
+func (r *Repository) SaveLastKnownPrices(ctx context.Context, prices []models.InstrumentPrice) error {
+	if len(prices) == 0 {
+		return nil
+	}
+	figis := make([]string, len(prices))
+	names := make([]string, len(prices))
+	values := make([]float64, len(prices))
+	for i, p := range prices {
+		figis[i], names[i] = p.FIGI, p.Name
+		if p.LastPrice != nil {
+			values[i] = *p.LastPrice
+		}
+	}
+	_, err := r.DB.Exec(ctx, `
+	INSERT INTO instrument_prices (figi, name, last_price, last_price_at)
+	SELECT figi, name, price, now() FROM unnest($1::text[], $2::text[], $3::float8[]) AS p(figi, name, price)
+	ON CONFLICT (figi) DO UPDATE SET
+		name = EXCLUDED.name, last_price = EXCLUDED.last_price, last_price_at = EXCLUDED.last_price_at`,
+		figis, names, values,
+	)
+	return err
+}
+
+// SetManualPrice назначает цену вручную; nil сбрасывает ручную цену.
+func (r *Repository) SetManualPrice(ctx context.Context, figi string, price *float64) error {
+	_, err := r.DB.Exec(ctx, `
+	INSERT INTO instrument_prices (figi, manual_price, manual_price_at)
+	VALUES ($1, $2, now())
+	ON CONFLICT (figi) DO UPDATE SET
+		manual_price = EXCLUDED.manual_price, manual_price_at = EXCLUDED.manual_price_at`,
+		figi, price,
+	)
+	return err
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"tinvest_report/internal/models"
+)
+
+func TestSaveLastKnownPrices(t *testing.T) {
+	r := newTestRepository(t, "instrument_prices")
+	ctx := context.Background()
+
+	manual := 50.0
+	if err := r.SetManualPrice(ctx, "AAA", &manual); err != nil {
+		t.Fatal(err)
+	}
+	price := func(v float64) *float64 { return &v }
+	if err := r.SaveLastKnownPrices(ctx, []models.InstrumentPrice{
+		{FIGI: "AAA", Name: "Акция А", LastPrice: price(101.5)},
+		{FIGI: "BBB", Name: "Акция Б", LastPrice: price(7)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SaveLastKnownPrices(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	prices, err := r.GetInstrumentPrices(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := prices["AAA"]
+	if a.LastPrice == nil || *a.LastPrice != 101.5 || a.Name != "Акция А" || a.LastPriceAt == nil {
+		t.Errorf("AAA = %+v, want last price 101.5", a)
+	}
+	if a.ManualPrice == nil || *a.ManualPrice != manual {
+		t.Errorf("AAA manual price = %v, want it kept", a.ManualPrice)
+	}
+	if b := prices["BBB"]; b.LastPrice == nil || *b.LastPrice != 7 {
+		t.Errorf("BBB = %+v, want last price 7", b)
+	}
+}
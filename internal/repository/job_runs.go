@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/scheduler"
+)
+
+// jobLockClass — первая половина ключа advisory lock задач; вторая — hashtext(имя задачи).
+// Отличается от ключа миграций, чтобы задачи не ждали миграции и наоборот.
+const jobLockClass = 7_340_002
+
+// jobLockCheckInterval — как часто проверяется, что соединение с блокировкой живо.
+const jobLockCheckInterval = 15 * time.Second
+
+// JobLocker реализует scheduler.Locker на session-level advisory lock Postgres и таблице job_runs:
+// блокировка не даёт двум репликам выполнять задачу одновременно, а уникальный
+// (job_name, scheduled_at) — повторно выполнить уже отработанный слот расписания.
+// Блокировка держится на отдельном соединении вне пула: задача может идти долго,
+// и соединения пула нужны ей самой и HTTP-запросам.
+type JobLocker struct {
+	repo     *Repository
+	instance string
+}
+
+// NewJobLocker создаёт блокировщик; instance записывается в job_runs, чтобы было видно, какая реплика выполнила задачу.
+func (r *Repository) NewJobLocker(instance string) *JobLocker {
+	return &JobLocker{repo: r, instance: instance}
+}
+
+func (l *JobLocker) Acquire(ctx context.Context, job string, slot time.Time, manual bool) (scheduler.Lease, error) {
+	conn, err := pgx.ConnectConfig(ctx, l.repo.DB.Config().ConnConfig.Copy())
+	if err != nil {
+		return nil, err
+	}
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1, hashtext($2))`, jobLockClass, job).Scan(&locked); err != nil {
+		closeConn(conn)
+		return nil, err
+	}
+	if !locked {
+		closeConn(conn)
+		return nil, nil
+	}
+
+	lease := &jobLease{db: l.repo.DB, conn: conn, job: job, lost: make(chan struct{}), stop: make(chan struct{})}
+	runID, err := l.startRun(ctx, conn, job, slot, manual)
+	if err != nil || runID == 0 {
+		lease.unlock()
+		return nil, err
+	}
+	lease.runID = runID
+	lease.done.Add(1)
+	go lease.watch()
+	return lease, nil
+}
+
+// startRun закрывает запуски, брошенные упавшими репликами, и записывает новый.
+// Возвращает 0, если слот уже выполнен.
+func (l *JobLocker) startRun(ctx context.Context, conn *pgx.Conn, job string, slot time.Time, manual bool) (int64, error) {
+	// Блокировка уже захвачена, значение running у чужих записей означает, что их реплика пропала.
+	_, err := conn.Exec(ctx, `
+	UPDATE job_runs SET status = $2, finished_at = now(), error = 'реплика завершилась во время выполнения'
+	WHERE job_name = $1 AND status = $3`,
+		job, models.JobRunLost, models.JobRunRunning,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = conn.QueryRow(ctx, `
+	INSERT INTO job_runs (job_name, scheduled_at, manual, instance, status)
+	VALUES ($1,$2,$3,$4,$5)
+	ON CONFLICT (job_name, scheduled_at) DO NOTHING
+	RETURNING id`,
+		job, slot.UTC(), manual, l.instance, models.JobRunRunning,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
+
+type jobLease struct {
+	db    *pgxpool.Pool
+	conn  *pgx.Conn
+	job   string
+	runID int64
+
+	lost     chan struct{}
+	lostOnce sync.Once
+	stop     chan struct{}
+	done     sync.WaitGroup
+}
+
+func (l *jobLease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// watch периодически проверяет соединение: блокировка живёт, пока жива сессия.
+func (l *jobLease) watch() {
+	defer l.done.Done()
+	ticker := time.NewTicker(jobLockCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			err := l.conn.Ping(ctx)
+			cancel()
+			if err != nil {
+				slog.Warn("Потеряна блокировка задачи", "job", l.job, "error", err)
+				l.lostOnce.Do(func() { close(l.lost) })
+				return
+			}
+		}
+	}
+}
+
+func (l *jobLease) Release(runErr error) {
+	close(l.stop)
+	l.done.Wait()
+
+	status, errText := models.JobRunSuccess, ""
+	switch {
+	case errors.Is(runErr, scheduler.ErrLockLost):
+		status, errText = models.JobRunLost, runErr.Error()
+	case runErr != nil:
+		status, errText = models.JobRunFailed, runErr.Error()
+	}
+
+	// Запись идёт через пул: соединение с блокировкой может быть уже разорвано.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := l.db.Exec(ctx, `UPDATE job_runs SET status = $2, error = $3, finished_at = now() WHERE id = $1`,
+		l.runID, status, errText)
+	if err != nil {
+		slog.Warn("Не удалось записать результат задачи", "job", l.job, "error", err)
+	}
+	l.unlock()
+}
+
+// unlock закрывает соединение с блокировкой: вместе с сессией Postgres снимает и блокировку.
+func (l *jobLease) unlock() {
+	closeConn(l.conn)
+}
+
+func closeConn(conn *pgx.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := conn.Close(ctx); err != nil {
+		slog.Warn("Ошибка закрытия соединения блокировки задачи", "error", err)
+	}
+}
+
+// GetJobRuns возвращает последние запуски задачи, начиная с новых; пустое name — по всем задачам.
+func (r *Repository) GetJobRuns(ctx context.Context, name string, limit int) ([]models.JobRun, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT id, job_name, scheduled_at, manual, instance, status, error, started_at, finished_at
+		FROM job_runs
+		WHERE $1 = '' OR job_name = $1
+		ORDER BY started_at DESC, id DESC
+		LIMIT $2
+	`, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.JobRun, error) {
+		var j models.JobRun
+		err := row.Scan(&j.ID, &j.JobName, &j.ScheduledAt, &j.Manual, &j.Instance, &j.Status,
+			&j.Error, &j.StartedAt, &j.FinishedAt)
+		return j, err
+	})
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tinvest_report/internal/models"
+)
+
+func TestJobLocker(t *testing.T) {
+	r := newTestRepository(t, "job_runs")
+	ctx := context.Background()
+	a, b := r.NewJobLocker("a"), r.NewJobLocker("b")
+	slot := time.Date(2024, 1, 10, 4, 0, 0, 0, time.UTC)
+
+	lease, err := a.Acquire(ctx, "equity", slot, false)
+	if err != nil || lease == nil {
+		t.Fatalf("first Acquire = %v, %v", lease, err)
+	}
+	// Блокировка держится на отдельном соединении, а не на соединении пула.
+	if n := r.DB.Stat().AcquiredConns(); n != 0 {
+		t.Errorf("pool connections held by the lease = %d, want 0", n)
+	}
+	if other, err := b.Acquire(ctx, "equity", slot.Add(time.Hour), false); err != nil || other != nil {
+		t.Errorf("Acquire while locked = %v, %v; want nil lease", other, err)
+	}
+	lease.Release(nil)
+
+	// Отработанный слот не выполняется повторно, следующий — выполняется.
+	if again, err := b.Acquire(ctx, "equity", slot, false); err != nil || again != nil {
+		t.Errorf("Acquire of a finished slot = %v, %v; want nil lease", again, err)
+	}
+	next, err := b.Acquire(ctx, "equity", slot.AddDate(0, 0, 1), false)
+	if err != nil || next == nil {
+		t.Fatalf("Acquire of the next slot = %v, %v", next, err)
+	}
+	next.Release(nil)
+
+	runs, err := r.GetJobRuns(ctx, "equity", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].Instance != "b" || runs[1].Instance != "a" || runs[0].Status != models.JobRunSuccess {
+		t.Errorf("runs = %+v", runs)
+	}
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+)
+
+const manualOperationColumns = `
+	id, currency, payment, operation_date, type, operation_type,
+	figi, quantity, price, comment, created_at, updated_at`
+
+func scanManualOperation(row pgx.Row) (models.ManualOperation, error) {
+	var op models.ManualOperation
+	err := row.Scan(
+		&op.ID, &op.Currency, &op.FloatPayment, &op.Date, &op.Type, &op.OperationType,
+		&op.FIGI, &op.Quantity, &op.Price, &op.Comment, &op.CreatedAt, &op.UpdatedAt,
+	)
+	return op, err
+}
+
+func (r *Repository) ListManualOperations(ctx context.Context) ([]models.ManualOperation, error) {
+	rows, err := r.DB.Query(ctx, `SELECT `+manualOperationColumns+` FROM manual_operations ORDER BY operation_date, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ops []models.ManualOperation
+	for rows.Next() {
+		op, err := scanManualOperation(rows)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	return ops, rows.Err()
+}
+
+// GetManualOperation возвращает pgx.ErrNoRows, если операции нет.
+func (r *Repository) GetManualOperation(ctx context.Context, id int) (models.ManualOperation, error) {
+	row := r.DB.QueryRow(ctx, `SELECT `+manualOperationColumns+` FROM manual_operations WHERE id = $1`, id)
+	return scanManualOperation(row)
+}
+
+// CreateManualOperation сохраняет операцию и запись журнала от имени пользователя changedBy.
+func (r *Repository) CreateManualOperation(ctx context.Context, op models.ManualOperation, changedBy string) (models.ManualOperation, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return models.ManualOperation{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	row := tx.QueryRow(ctx, `
+	INSERT INTO manual_operations (
+		currency, payment, operation_date, type, operation_type,
+		figi, quantity, price, comment
+	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+	RETURNING `+manualOperationColumns,
+		op.Currency, op.FloatPayment, op.Date, op.Type, op.OperationType,
+		op.FIGI, op.Quantity, op.Price, op.Comment,
+	)
+	created, err := scanManualOperation(row)
+	if err != nil {
+		return models.ManualOperation{}, err
+	}
+
+	if err := insertManualOperationAudit(ctx, tx, created.ID, "create", nil, &created, changedBy); err != nil {
+		return models.ManualOperation{}, err
+	}
+	return created, tx.Commit(ctx)
+}
+
+// UpdateManualOperation обновляет операцию; pgx.ErrNoRows, если её нет.
+func (r *Repository) UpdateManualOperation(ctx context.Context, id int, op models.ManualOperation, changedBy string) (models.ManualOperation, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return models.ManualOperation{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	old, err := scanManualOperation(tx.QueryRow(ctx,
+		`SELECT `+manualOperationColumns+` FROM manual_operations WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return models.ManualOperation{}, err
+	}
+
+	row := tx.QueryRow(ctx, `
+	UPDATE manual_operations SET
+		currency = $2, payment = $3, operation_date = $4, type = $5, operation_type = $6,
+		figi = $7, quantity = $8, price = $9, comment = $10, updated_at = now()
+	WHERE id = $1
+	RETURNING `+manualOperationColumns,
+		id, op.Currency, op.FloatPayment, op.Date, op.Type, op.OperationType,
+		op.FIGI, op.Quantity, op.Price, op.Comment,
+	)
+	updated, err := scanManualOperation(row)
+	if err != nil {
+		return models.ManualOperation{}, err
+	}
+
+	if err := insertManualOperationAudit(ctx, tx, id, "update", &old, &updated, changedBy); err != nil {
+		return models.ManualOperation{}, err
+	}
+	return updated, tx.Commit(ctx)
+}
+
+// DeleteManualOperation удаляет операцию; её последнее состояние остаётся в журнале.
+func (r *Repository) DeleteManualOperation(ctx context.Context, id int, changedBy string) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	old, err := scanManualOperation(tx.QueryRow(ctx,
+		`DELETE FROM manual_operations WHERE id = $1 RETURNING `+manualOperationColumns, id))
+	if err != nil {
+		return err
+	}
+
+	if err := insertManualOperationAudit(ctx, tx, id, "delete", &old, nil, changedBy); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) GetManualOperationAudit(ctx context.Context, id int) ([]models.ManualOperationAudit, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT id, operation_id, action, old_data, new_data, changed_at, changed_by
+		FROM manual_operations_audit
+		WHERE operation_id = $1
+		ORDER BY changed_at, id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.ManualOperationAudit
+	for rows.Next() {
+		var a models.ManualOperationAudit
+		if err := rows.Scan(&a.ID, &a.OperationID, &a.Action, &a.OldData, &a.NewData, &a.ChangedAt, &a.ChangedBy); err != nil {
+			return nil, err
+		}
+		entries = append(entries, a)
+	}
+	return entries, rows.Err()
+}
+
+func insertManualOperationAudit(ctx context.Context, tx pgx.Tx, id int, action string, oldOp, newOp *models.ManualOperation, changedBy string) error {
+	_, err := tx.Exec(ctx, `
+	INSERT INTO manual_operations_audit (operation_id, action, old_data, new_data, changed_at, changed_by)
+	VALUES ($1, $2, $3, $4, now(), $5)`,
+		id, action, oldOp, newOp, changedBy,
+	)
+	return err
+}
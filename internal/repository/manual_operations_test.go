@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tinvest_report/internal/models"
+)
+
+func TestManualOperationAuditRecordsUser(t *testing.T) {
+	r := newTestRepository(t, "manual_operations", "manual_operations_audit")
+	ctx := context.Background()
+	op := models.ManualOperation{
+		Currency: "rub", OperationType: "OPERATION_TYPE_INPUT", FloatPayment: 1000,
+		Date: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+	}
+
+	created, err := r.CreateManualOperation(ctx, op, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	op.FloatPayment = 1500
+	if _, err := r.UpdateManualOperation(ctx, created.ID, op, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeleteManualOperation(ctx, created.ID, "admin-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := r.GetManualOperationAudit(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ action, user string }{{"create", "alice"}, {"update", "bob"}, {"delete", "admin-token"}}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d audit entries, want %d", len(entries), len(want))
+	}
+	for i, w := range want {
+		if entries[i].Action != w.action || entries[i].ChangedBy != w.user {
+			t.Errorf("entry %d = %s by %q, want %s by %q", i, entries[i].Action, entries[i].ChangedBy, w.action, w.user)
+		}
+	}
+}
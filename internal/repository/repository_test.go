@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"tinvest_report/db"
+)
+
+// newTestRepository подключается к TEST_POSTGRES_DSN и применяет миграции. Без переменной
+// тесты с БД пропускаются. База должна быть отдельной: таблицы теста очищаются.
+func newTestRepository(t *testing.T, tables ...string) *Repository {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN не задан")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	if err := db.MigrateUp(ctx, pool); err != nil {
+		t.Fatal(err)
+	}
+	truncate := func() {
+		for _, table := range tables {
+			if _, err := pool.Exec(ctx, `TRUNCATE `+table+` RESTART IDENTITY CASCADE`); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	truncate()
+	t.Cleanup(truncate)
+	return NewRepository(pool)
+}
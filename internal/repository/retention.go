@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+)
+
+// PruneSummaries прореживает снимки старше hourlyCutoff: до dailyCutoff остаётся последний
+// снимок дня, раньше — последний снимок месяца (отдельно по каждому счёту).
+// При dryRun ничего не удаляется, но прогон всё равно записывается в историю.
+func (r *Repository) PruneSummaries(ctx context.Context, run models.RetentionRun) (models.RetentionRun, error) {
+	err := pgx.BeginFunc(ctx, r.DB, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+		WITH bucketed AS (
+			SELECT id, account_id, created_at,
+				CASE WHEN created_at >= $2 THEN 'day' ELSE 'month' END AS tier,
+				CASE WHEN created_at >= $2 THEN date_trunc('day', created_at)
+				     ELSE date_trunc('month', created_at) END AS bucket
+			FROM summary
+			WHERE created_at < $1
+		), ranked AS (
+			SELECT id, account_id, created_at, tier,
+				row_number() OVER (PARTITION BY account_id, bucket ORDER BY created_at DESC, id DESC) AS rn
+			FROM bucketed
+		)
+		SELECT id, account_id, created_at, tier FROM ranked WHERE rn > 1 ORDER BY created_at, id`,
+			run.HourlyCutoff, run.DailyCutoff,
+		)
+		if err != nil {
+			return err
+		}
+		pruned, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.PrunedSnapshot, error) {
+			var p models.PrunedSnapshot
+			err := row.Scan(&p.ID, &p.AccountID, &p.CreatedAt, &p.Tier)
+			return p, err
+		})
+		if err != nil {
+			return err
+		}
+
+		if !run.DryRun && len(pruned) > 0 {
+			ids := make([]int, len(pruned))
+			for i, p := range pruned {
+				ids[i] = p.ID
+			}
+			if _, err := tx.Exec(ctx, `DELETE FROM summary WHERE id = ANY($1)`, ids); err != nil {
+				return err
+			}
+		}
+
+		run.Pruned = pruned
+		run.PrunedCount = len(pruned)
+		return tx.QueryRow(ctx, `
+		INSERT INTO summary_retention_runs (
+			trigger, hourly_days, daily_months, dry_run, hourly_cutoff, daily_cutoff, pruned_count, pruned
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, run_at`,
+			run.Trigger, run.HourlyDays, run.DailyMonths, run.DryRun, run.HourlyCutoff, run.DailyCutoff,
+			run.PrunedCount, run.Pruned,
+		).Scan(&run.ID, &run.RunAt)
+	})
+	return run, err
+}
+
+// GetRetentionRuns возвращает последние прогоны очистки, начиная с новых.
+func (r *Repository) GetRetentionRuns(ctx context.Context, limit int) ([]models.RetentionRun, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT id, run_at, trigger, hourly_days, daily_months, dry_run, hourly_cutoff, daily_cutoff, pruned_count, pruned
+		FROM summary_retention_runs
+		ORDER BY run_at DESC, id DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []models.RetentionRun
+	for rows.Next() {
+		var run models.RetentionRun
+		if err := rows.Scan(
+			&run.ID, &run.RunAt, &run.Trigger, &run.HourlyDays, &run.DailyMonths, &run.DryRun,
+			&run.HourlyCutoff, &run.DailyCutoff, &run.PrunedCount, &run.Pruned,
+		); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// RetentionPolicyReviewed сообщает, запускалась ли очистка с этой политикой через API.
+func (r *Repository) RetentionPolicyReviewed(ctx context.Context, policy models.RetentionPolicy) (bool, error) {
+	var reviewed bool
+	err := r.DB.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM summary_retention_runs
+			WHERE trigger = $1 AND hourly_days = $2 AND daily_months = $3
+		)`,
+		models.SummaryTriggerManual, policy.HourlyDays, policy.DailyMonths,
+	).Scan(&reviewed)
+	return reviewed, err
+}
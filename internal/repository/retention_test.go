@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tinvest_report/internal/models"
+)
+
+func summaryIDs(t *testing.T, r *Repository) map[int]bool {
+	t.Helper()
+	rows, err := r.DB.Query(context.Background(), `SELECT id FROM summary`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	ids := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids[id] = true
+	}
+	return ids
+}
+
+func TestPruneSummaries(t *testing.T) {
+	r := newTestRepository(t, "summary", "summary_retention_runs")
+	ctx := context.Background()
+
+	hourlyCutoff := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	dailyCutoff := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(month time.Month, d, h int) time.Time { return time.Date(2025, month, d, h, 0, 0, 0, time.UTC) }
+
+	keep := map[int]string{
+		insertSummaryAt(t, r, "a", at(6, 2, 9)):   "newer than hourly cutoff",
+		insertSummaryAt(t, r, "a", at(6, 2, 10)):  "newer than hourly cutoff",
+		insertSummaryAt(t, r, "a", at(5, 10, 18)): "last of the day",
+		insertSummaryAt(t, r, "b", at(5, 10, 9)):  "last of the day for another account",
+		insertSummaryAt(t, r, "a", at(1, 31, 23)): "last of the month",
+	}
+	drop := map[int]string{
+		insertSummaryAt(t, r, "a", at(5, 10, 9)):  "earlier the same day",
+		insertSummaryAt(t, r, "a", at(1, 5, 12)):  "earlier the same month",
+		insertSummaryAt(t, r, "a", at(1, 31, 22)): "earlier the same month",
+	}
+
+	policy := models.RetentionPolicy{HourlyDays: 30, DailyMonths: 3}
+	if reviewed, err := r.RetentionPolicyReviewed(ctx, policy); err != nil || reviewed {
+		t.Fatalf("policy reviewed before any manual run: %v, %v", reviewed, err)
+	}
+
+	dry, err := r.PruneSummaries(ctx, models.RetentionRun{
+		Trigger: models.SummaryTriggerManual, HourlyDays: policy.HourlyDays, DailyMonths: policy.DailyMonths,
+		DryRun: true, HourlyCutoff: hourlyCutoff, DailyCutoff: dailyCutoff,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dry.PrunedCount != len(drop) {
+		t.Fatalf("dry run pruned %d, want %d: %+v", dry.PrunedCount, len(drop), dry.Pruned)
+	}
+	if got := summaryIDs(t, r); len(got) != len(keep)+len(drop) {
+		t.Fatalf("dry run deleted snapshots: %d left", len(got))
+	}
+
+	if reviewed, err := r.RetentionPolicyReviewed(ctx, policy); err != nil || !reviewed {
+		t.Fatalf("policy not reviewed after manual dry run: %v, %v", reviewed, err)
+	}
+	if reviewed, err := r.RetentionPolicyReviewed(ctx, models.RetentionPolicy{HourlyDays: 7, DailyMonths: 3}); err != nil || reviewed {
+		t.Fatalf("another policy counted as reviewed: %v, %v", reviewed, err)
+	}
+
+	run, err := r.PruneSummaries(ctx, models.RetentionRun{
+		Trigger: models.SummaryTriggerAuto, HourlyDays: policy.HourlyDays, DailyMonths: policy.DailyMonths,
+		HourlyCutoff: hourlyCutoff, DailyCutoff: dailyCutoff,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.PrunedCount != len(drop) {
+		t.Fatalf("pruned %d, want %d", run.PrunedCount, len(drop))
+	}
+	left := summaryIDs(t, r)
+	for id, why := range keep {
+		if !left[id] {
+			t.Errorf("snapshot %d deleted, want kept: %s", id, why)
+		}
+	}
+	for id, why := range drop {
+		if left[id] {
+			t.Errorf("snapshot %d kept, want deleted: %s", id, why)
+		}
+	}
+
+	runs, err := r.GetRetentionRuns(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].DryRun || !runs[1].DryRun || runs[0].Trigger != models.SummaryTriggerAuto || runs[1].HourlyDays != 30 {
+		t.Errorf("retention runs = %+v, want real run after dry run", runs)
+	}
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+)
+
+func (r *Repository) GetSecurityTransferBases(ctx context.Context) (map[string]models.SecurityTransferBasis, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT operation_id, price, acquired_at, comment, updated_at
+		FROM security_transfers
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bases := make(map[string]models.SecurityTransferBasis)
+	for rows.Next() {
+		var b models.SecurityTransferBasis
+		if err := rows.Scan(&b.OperationID, &b.Price, &b.AcquiredAt, &b.Comment, &b.UpdatedAt); err != nil {
+			return nil, err
+		}
+		bases[b.OperationID] = b
+	}
+	return bases, rows.Err()
+}
+
+func (r *Repository) SaveSecurityTransferBasis(ctx context.Context, b models.SecurityTransferBasis) error {
+	_, err := r.DB.Exec(ctx, `
+	INSERT INTO security_transfers (operation_id, price, acquired_at, comment, updated_at)
+	VALUES ($1, $2, $3, $4, now())
+	ON CONFLICT (operation_id) DO UPDATE SET
+		price = EXCLUDED.price, acquired_at = EXCLUDED.acquired_at,
+		comment = EXCLUDED.comment, updated_at = EXCLUDED.updated_at`,
+		b.OperationID, b.Price, b.AcquiredAt, b.Comment,
+	)
+	return err
+}
+
+// DeleteSecurityTransferBasis возвращает pgx.ErrNoRows, если стоимость не была указана.
+func (r *Repository) DeleteSecurityTransferBasis(ctx context.Context, operationID string) error {
+	tag, err := r.DB.Exec(ctx, `DELETE FROM security_transfers WHERE operation_id = $1`, operationID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -2,8 +2,14 @@ package repository
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"tinvest_report/internal/models"
 )
@@ -16,75 +22,176 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{DB: db}
 }
 
-func (r *Repository) SaveSummary(ctx context.Context, summary models.Summary) error {
+const summaryColumns = `
+	id, total_input, total_output, turnover, total_buys,
+	total_sells, portfolio_value, commissions, taxes, net_stock_profit,
+	account_id, period_from, period_to, currency, trigger, app_version, payload, created_at`
+
+// SaveSummary сохраняет отчёт и возвращает сохранённую запись с ID и временем создания.
+func (r *Repository) SaveSummary(ctx context.Context, summary models.Summary) (models.Summary, error) {
 	query := `
 	INSERT INTO summary (
 		total_input, total_output, turnover, total_buys,
-		total_sells, portfolio_value, commissions, taxes, net_stock_profit, created_at
-	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9, now())`
+		total_sells, portfolio_value, commissions, taxes, net_stock_profit,
+		account_id, period_from, period_to, currency, trigger, app_version, payload, created_at
+	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16, now())
+	RETURNING ` + summaryColumns
 
-	_, err := r.DB.Exec(ctx, query,
+	row := r.DB.QueryRow(ctx, query,
 		summary.TotalInput, summary.TotalOutput, summary.Turnover, summary.TotalBuys,
 		summary.TotalSells, summary.PortfolioValue, summary.Commissions,
 		summary.Taxes, summary.NetStockProfit,
+		summary.AccountID, summary.PeriodFrom, summary.PeriodTo, summary.Currency,
+		summary.Trigger, summary.AppVersion, summary.Instruments,
 	)
-	return err
+	return scanSummary(row)
 }
-func (r *Repository) GetSummaries(ctx context.Context, date string) ([]models.Summary, error) {
-	var summaries []models.Summary
 
-	if date != "" {
-		query := `
-			SELECT * FROM summary
-			WHERE created_at >= $1 AND created_at < $2
-			ORDER BY created_at DESC
-		`
-		start, err := time.Parse("2006-01-02", date)
-		if err != nil {
-			return nil, err
-		}
-		end := start.Add(24 * time.Hour)
+// SummaryQuery — фильтры, порядок и пагинация для GetSummaries.
+type SummaryQuery struct {
+	From      *time.Time // включительно
+	To        *time.Time // не включительно
+	AccountID string
+	// Aggregate — day, week или month: из каждого периода берётся последний снимок каждого счёта.
+	Aggregate string
+	Desc      bool
+	Limit     int
+	Cursor    *SummaryCursor
+}
 
-		rows, err := r.DB.Query(ctx, query, start, end)
-		if err != nil {
-			return nil, err
-		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var s models.Summary
-			err := rows.Scan(
-				&s.ID, &s.TotalInput, &s.TotalOutput, &s.Turnover, &s.TotalBuys,
-				&s.TotalSells, &s.PortfolioValue, &s.Commissions, &s.Taxes,
-				&s.NetStockProfit, &s.CreatedAt,
-			)
-			if err != nil {
-				return nil, err
-			}
-			summaries = append(summaries, s)
+// SummaryCursor — позиция последней отданной записи для keyset-пагинации.
+type SummaryCursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+func (c SummaryCursor) Encode() string {
+	raw := c.CreatedAt.Format(time.RFC3339Nano) + "|" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeSummaryCursor(s string) (*SummaryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	at, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	return &SummaryCursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+var errInvalidCursor = errors.New("некорректный cursor")
+
+// GetSummaries возвращает страницу снимков и курсор следующей страницы (nil, если страница последняя).
+func (r *Repository) GetSummaries(ctx context.Context, q SummaryQuery) ([]models.Summary, *SummaryCursor, error) {
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if q.From != nil {
+		where = append(where, "created_at >= "+arg(*q.From))
+	}
+	if q.To != nil {
+		where = append(where, "created_at < "+arg(*q.To))
+	}
+	if q.AccountID != "" {
+		where = append(where, "account_id = "+arg(q.AccountID))
+	}
+
+	source := "summary"
+	if q.Aggregate != "" {
+		switch q.Aggregate {
+		case "day", "week", "month":
+		default:
+			return nil, nil, fmt.Errorf("неизвестный период агрегации %s", q.Aggregate)
 		}
-		return summaries, nil
+		bucket := "date_trunc('" + q.Aggregate + "', created_at)"
+		source = `(
+			SELECT DISTINCT ON (account_id, ` + bucket + `) ` + summaryColumns + `
+			FROM summary` + whereClause(where) + `
+			ORDER BY account_id, ` + bucket + `, created_at DESC, id DESC
+		) s`
+		where = nil
+	}
+
+	order, cmp := "ASC", ">"
+	if q.Desc {
+		order, cmp = "DESC", "<"
+	}
+	if q.Cursor != nil {
+		where = append(where, "(created_at, id) "+cmp+" ("+arg(q.Cursor.CreatedAt)+", "+arg(q.Cursor.ID)+")")
+	}
+
+	query := `SELECT ` + summaryColumns + ` FROM ` + source + whereClause(where) +
+		` ORDER BY created_at ` + order + `, id ` + order +
+		` LIMIT ` + arg(q.Limit+1)
+
+	summaries, err := r.querySummaries(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
 	}
+	if len(summaries) <= q.Limit {
+		return summaries, nil, nil
+	}
+
+	summaries = summaries[:q.Limit]
+	last := summaries[len(summaries)-1]
+	return summaries, &SummaryCursor{CreatedAt: last.CreatedAt, ID: last.ID}, nil
+}
+
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
 
-	// Без фильтра по дате
-	query := `SELECT * FROM summary ORDER BY created_at DESC`
-	rows, err := r.DB.Query(ctx, query)
+func (r *Repository) querySummaries(ctx context.Context, query string, args ...any) ([]models.Summary, error) {
+	rows, err := r.DB.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	var summaries []models.Summary
 	for rows.Next() {
-		var s models.Summary
-		err := rows.Scan(
-			&s.ID, &s.TotalInput, &s.TotalOutput, &s.Turnover, &s.TotalBuys,
-			&s.TotalSells, &s.PortfolioValue, &s.Commissions, &s.Taxes,
-			&s.NetStockProfit, &s.CreatedAt,
-		)
+		s, err := scanSummary(rows)
 		if err != nil {
 			return nil, err
 		}
 		summaries = append(summaries, s)
 	}
-	return summaries, nil
+	return summaries, rows.Err()
+}
+
+func scanSummary(row pgx.Row) (models.Summary, error) {
+	var s models.Summary
+	err := row.Scan(
+		&s.ID, &s.TotalInput, &s.TotalOutput, &s.Turnover, &s.TotalBuys,
+		&s.TotalSells, &s.PortfolioValue, &s.Commissions, &s.Taxes,
+		&s.NetStockProfit, &s.AccountID, &s.PeriodFrom, &s.PeriodTo,
+		&s.Currency, &s.Trigger, &s.AppVersion, &s.Instruments, &s.CreatedAt,
+	)
+	return s, err
+}
+
+// LastSummaryAt возвращает время последнего снимка счёта с данным trigger; nil, если снимков нет.
+func (r *Repository) LastSummaryAt(ctx context.Context, accountID, trigger string) (*time.Time, error) {
+	var at *time.Time
+	err := r.DB.QueryRow(ctx, `SELECT max(created_at) FROM summary WHERE account_id = $1 AND trigger = $2`,
+		accountID, trigger).Scan(&at)
+	return at, err
 }
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func insertSummaryAt(t *testing.T, r *Repository, accountID string, at time.Time) int {
+	t.Helper()
+	var id int
+	err := r.DB.QueryRow(context.Background(),
+		`INSERT INTO summary (account_id, created_at) VALUES ($1, $2) RETURNING id`, accountID, at).Scan(&id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestSummaryCursorRoundTrip(t *testing.T) {
+	c := SummaryCursor{CreatedAt: time.Date(2025, 3, 1, 10, 30, 0, 123456789, time.UTC), ID: 42}
+	got, err := DecodeSummaryCursor(c.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.CreatedAt.Equal(c.CreatedAt) || got.ID != c.ID {
+		t.Errorf("decoded %+v, want %+v", got, c)
+	}
+}
+
+func TestDecodeSummaryCursorInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"!!!",
+		"bm8tc2VwYXJhdG9y",               // "no-separator"
+		"bm90LWEtZGF0ZXw0Mg",             // "not-a-date|42"
+		"MjAyNS0wMy0wMVQxMDozMDowMFp8eA", // "2025-03-01T10:30:00Z|x"
+	} {
+		if _, err := DecodeSummaryCursor(s); err != errInvalidCursor {
+			t.Errorf("DecodeSummaryCursor(%q) error = %v, want errInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestGetSummariesAggregatePerAccount(t *testing.T) {
+	r := newTestRepository(t, "summary")
+	ctx := context.Background()
+	at := func(d, h int) time.Time { return time.Date(2025, 5, d, h, 0, 0, 0, time.UTC) }
+
+	insertSummaryAt(t, r, "a", at(1, 9))
+	lastA := insertSummaryAt(t, r, "a", at(1, 18))
+	lastB := insertSummaryAt(t, r, "b", at(1, 12))
+	nextDay := insertSummaryAt(t, r, "a", at(2, 9))
+
+	got, next, err := r.GetSummaries(ctx, SummaryQuery{Aggregate: "day", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != nil {
+		t.Errorf("next cursor = %+v, want nil", next)
+	}
+	want := []int{lastB, lastA, nextDay}
+	if len(got) != len(want) {
+		t.Fatalf("got %d snapshots, want one per account and day: %+v", len(got), got)
+	}
+	for i, id := range want {
+		if got[i].ID != id {
+			t.Errorf("snapshot %d id = %d, want %d", i, got[i].ID, id)
+		}
+	}
+}
+
+func TestGetSummariesCursor(t *testing.T) {
+	r := newTestRepository(t, "summary")
+	ctx := context.Background()
+	var ids []int
+	for h := 0; h < 5; h++ {
+		ids = append(ids, insertSummaryAt(t, r, "a", time.Date(2025, 5, 1, h, 0, 0, 0, time.UTC)))
+	}
+
+	var seen []int
+	q := SummaryQuery{Desc: true, Limit: 2}
+	for page := 0; page < 5; page++ {
+		got, next, err := r.GetSummaries(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range got {
+			seen = append(seen, s.ID)
+		}
+		if next == nil {
+			break
+		}
+		// Курсор проходит через клиента в закодированном виде.
+		if q.Cursor, err = DecodeSummaryCursor(next.Encode()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(seen) != len(ids) {
+		t.Fatalf("seen %v, want all of %v", seen, ids)
+	}
+	for i := range ids {
+		if seen[i] != ids[len(ids)-1-i] {
+			t.Fatalf("seen %v, want %v in reverse order", seen, ids)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+)
+
+func (r *Repository) ListTags(ctx context.Context) ([]models.Tag, error) {
+	rows, err := r.DB.Query(ctx, `SELECT name, description, created_at FROM tags ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+	for rows.Next() {
+		var t models.Tag
+		if err := rows.Scan(&t.Name, &t.Description, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// SaveTag создаёт метку или обновляет её описание.
+func (r *Repository) SaveTag(ctx context.Context, t models.Tag) (models.Tag, error) {
+	err := r.DB.QueryRow(ctx, `
+	INSERT INTO tags (name, description) VALUES ($1, $2)
+	ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description
+	RETURNING name, description, created_at`,
+		t.Name, t.Description,
+	).Scan(&t.Name, &t.Description, &t.CreatedAt)
+	return t, err
+}
+
+// DeleteTag удаляет метку вместе с назначениями; pgx.ErrNoRows, если метки нет.
+func (r *Repository) DeleteTag(ctx context.Context, name string) error {
+	tag, err := r.DB.Exec(ctx, `DELETE FROM tags WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *Repository) GetTagAssignments(ctx context.Context) (models.TagAssignments, error) {
+	out := models.TagAssignments{
+		Instruments: make(map[string][]string),
+		Operations:  make(map[string][]string),
+	}
+	if err := r.collectTags(ctx, `SELECT figi, tag FROM instrument_tags ORDER BY figi, tag`, out.Instruments); err != nil {
+		return out, err
+	}
+	err := r.collectTags(ctx, `SELECT operation_id, tag FROM operation_tags ORDER BY operation_id, tag`, out.Operations)
+	return out, err
+}
+
+func (r *Repository) collectTags(ctx context.Context, query string, into map[string][]string) error {
+	rows, err := r.DB.Query(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key, tag string
+		if err := rows.Scan(&key, &tag); err != nil {
+			return err
+		}
+		into[key] = append(into[key], tag)
+	}
+	return rows.Err()
+}
+
+// SetInstrumentTags заменяет метки инструмента; отсутствующие метки создаются.
+func (r *Repository) SetInstrumentTags(ctx context.Context, figi string, tags []string) error {
+	return r.replaceTags(ctx, "instrument_tags", "figi", figi, tags)
+}
+
+// SetOperationTags заменяет метки операции; отсутствующие метки создаются.
+func (r *Repository) SetOperationTags(ctx context.Context, operationID string, tags []string) error {
+	return r.replaceTags(ctx, "operation_tags", "operation_id", operationID, tags)
+}
+
+func (r *Repository) replaceTags(ctx context.Context, table, column, key string, tags []string) error {
+	return pgx.BeginFunc(ctx, r.DB, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE `+column+` = $1`, key); err != nil {
+			return err
+		}
+		for _, t := range tags {
+			if _, err := tx.Exec(ctx, `INSERT INTO tags (name) VALUES ($1) ON CONFLICT DO NOTHING`, t); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(ctx, `INSERT INTO `+table+` (`+column+`, tag) VALUES ($1, $2)`, key, t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
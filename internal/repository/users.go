@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+)
+
+// ErrLastAdmin возвращается, если изменение оставило бы API без администраторов.
+var ErrLastAdmin = errors.New("нельзя удалить или понизить последнего администратора")
+
+const userColumns = `id, name, role, key_prefix, created_at, last_used_at`
+
+func scanUser(row pgx.Row) (models.User, error) {
+	var u models.User
+	err := row.Scan(&u.ID, &u.Name, &u.Role, &u.KeyPrefix, &u.CreatedAt, &u.LastUsedAt)
+	return u, err
+}
+
+func (r *Repository) ListUsers(ctx context.Context) ([]models.User, error) {
+	rows, err := r.DB.Query(ctx, `SELECT `+userColumns+` FROM users ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// CreateUser создаёт пользователя с хэшем ключа keyHash; pgx.ErrNoRows, если имя занято.
+func (r *Repository) CreateUser(ctx context.Context, name, role, keyPrefix, keyHash string) (models.User, error) {
+	return scanUser(r.DB.QueryRow(ctx, `
+	INSERT INTO users (name, role, key_prefix, key_hash) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (name) DO NOTHING
+	RETURNING `+userColumns,
+		name, role, keyPrefix, keyHash,
+	))
+}
+
+// GetUserByKeyHash возвращает владельца ключа; pgx.ErrNoRows, если ключ неизвестен.
+func (r *Repository) GetUserByKeyHash(ctx context.Context, keyHash string) (models.User, error) {
+	return scanUser(r.DB.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE key_hash = $1`, keyHash))
+}
+
+// UpdateUserRole меняет роль; pgx.ErrNoRows, если пользователя нет, ErrLastAdmin — если
+// понижается последний администратор.
+func (r *Repository) UpdateUserRole(ctx context.Context, id int64, role string) (models.User, error) {
+	var u models.User
+	err := pgx.BeginFunc(ctx, r.DB, func(tx pgx.Tx) error {
+		if role != models.RoleAdmin {
+			if err := checkNotLastAdmin(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+		var err error
+		u, err = scanUser(tx.QueryRow(ctx, `UPDATE users SET role = $2 WHERE id = $1 RETURNING `+userColumns, id, role))
+		return err
+	})
+	return u, err
+}
+
+// checkNotLastAdmin блокирует строки администраторов до конца транзакции и возвращает
+// ErrLastAdmin, если id — единственный из них. Из-за блокировки два одновременных запроса
+// не могут удалить или понизить двух последних администраторов: второй дождётся первого
+// и увидит уже одного.
+func checkNotLastAdmin(ctx context.Context, tx pgx.Tx, id int64) error {
+	rows, err := tx.Query(ctx, `SELECT id FROM users WHERE role = $1 ORDER BY id FOR UPDATE`, models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	admins, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return err
+	}
+	if len(admins) == 1 && admins[0] == id {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// ReplaceUserKey заменяет ключ пользователя, старый ключ сразу перестаёт действовать.
+func (r *Repository) ReplaceUserKey(ctx context.Context, id int64, keyPrefix, keyHash string) (models.User, error) {
+	return scanUser(r.DB.QueryRow(ctx, `
+	UPDATE users SET key_prefix = $2, key_hash = $3, last_used_at = NULL WHERE id = $1
+	RETURNING `+userColumns,
+		id, keyPrefix, keyHash,
+	))
+}
+
+// TouchUser отмечает время at последнего запроса пользователя. last_used_at хранится
+// без часового пояса, поэтому время передаётся в UTC.
+func (r *Repository) TouchUser(ctx context.Context, id int64, at time.Time) error {
+	_, err := r.DB.Exec(ctx, `UPDATE users SET last_used_at = $2 WHERE id = $1`, id, at.UTC())
+	return err
+}
+
+// DeleteUser удаляет пользователя вместе с его ключом; pgx.ErrNoRows, если пользователя нет,
+// ErrLastAdmin — если это последний администратор.
+func (r *Repository) DeleteUser(ctx context.Context, id int64) error {
+	return pgx.BeginFunc(ctx, r.DB, func(tx pgx.Tx) error {
+		if err := checkNotLastAdmin(ctx, tx, id); err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+		return nil
+	})
+}
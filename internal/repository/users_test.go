@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+)
+
+func TestLastAdminIsKept(t *testing.T) {
+	r := newTestRepository(t, "users")
+	ctx := context.Background()
+	first, err := r.CreateUser(ctx, "first", models.RoleAdmin, "tir_a", "hash-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := r.CreateUser(ctx, "second", models.RoleAdmin, "tir_b", "hash-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.UpdateUserRole(ctx, first.ID, models.RoleViewer); err != nil {
+		t.Fatalf("demote with another admin left: %v", err)
+	}
+	if _, err := r.UpdateUserRole(ctx, second.ID, models.RoleViewer); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("demote of the last admin = %v, want ErrLastAdmin", err)
+	}
+	if err := r.DeleteUser(ctx, second.ID); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("delete of the last admin = %v, want ErrLastAdmin", err)
+	}
+	if err := r.DeleteUser(ctx, first.ID); err != nil {
+		t.Errorf("delete of a viewer: %v", err)
+	}
+	if err := r.DeleteUser(ctx, first.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("delete of a missing user = %v, want pgx.ErrNoRows", err)
+	}
+}
@@ -0,0 +1,145 @@
+// Package risk считает риск-метрики по ряду доходностей.
+// Ряд капитала дневной и календарный (включая выходные), поэтому в году PeriodsPerYear периодов.
+package risk
+
+import "math"
+
+const PeriodsPerYear = 365
+
+// Returns переводит ряд значений индекса в ряд доходностей между соседними точками.
+func Returns(index []float64) []float64 {
+	if len(index) < 2 {
+		return nil
+	}
+	out := make([]float64, 0, len(index)-1)
+	for i := 1; i < len(index); i++ {
+		if index[i-1] == 0 {
+			out = append(out, 0)
+			continue
+		}
+		out = append(out, index[i]/index[i-1]-1)
+	}
+	return out
+}
+
+func Mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// StdDev — выборочное стандартное отклонение.
+func StdDev(xs []float64) float64 {
+	if len(xs) < 2 {
+		return 0
+	}
+	m := Mean(xs)
+	var sum float64
+	for _, x := range xs {
+		sum += (x - m) * (x - m)
+	}
+	return math.Sqrt(sum / float64(len(xs)-1))
+}
+
+// AnnualizedVolatility — годовая волатильность доходностей.
+func AnnualizedVolatility(returns []float64) float64 {
+	return StdDev(returns) * math.Sqrt(PeriodsPerYear)
+}
+
+// AnnualizedReturn пересчитывает доходность за periods периодов в годовую.
+func AnnualizedReturn(totalReturn float64, periods int) float64 {
+	if periods == 0 || totalReturn <= -1 {
+		return 0
+	}
+	return math.Pow(1+totalReturn, PeriodsPerYear/float64(periods)) - 1
+}
+
+// MaxDrawdown возвращает глубину максимальной просадки индекса (отрицательное число или 0)
+// и её длительность в периодах: от пика до восстановления или до конца ряда.
+func MaxDrawdown(index []float64) (depth float64, duration int) {
+	if len(index) == 0 {
+		return 0, 0
+	}
+	peak, peakIdx := index[0], 0
+	var episodeDepth float64
+	for i, v := range index {
+		if v >= peak {
+			if episodeDepth < depth {
+				depth, duration = episodeDepth, i-peakIdx
+			}
+			peak, peakIdx, episodeDepth = v, i, 0
+			continue
+		}
+		if dd := v/peak - 1; dd < episodeDepth {
+			episodeDepth = dd
+		}
+	}
+	if episodeDepth < depth {
+		depth, duration = episodeDepth, len(index)-1-peakIdx
+	}
+	return depth, duration
+}
+
+// Sharpe — годовой коэффициент Шарпа; riskFreeRate — годовая безрисковая ставка (0.16 = 16%).
+func Sharpe(returns []float64, riskFreeRate float64) float64 {
+	excess := excessReturns(returns, riskFreeRate)
+	sd := StdDev(excess)
+	if sd == 0 {
+		return 0
+	}
+	return Mean(excess) / sd * math.Sqrt(PeriodsPerYear)
+}
+
+// Sortino — как Sharpe, но в знаменателе только отклонение вниз.
+func Sortino(returns []float64, riskFreeRate float64) float64 {
+	excess := excessReturns(returns, riskFreeRate)
+	if len(excess) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, r := range excess {
+		if r < 0 {
+			sum += r * r
+		}
+	}
+	downside := math.Sqrt(sum / float64(len(excess)))
+	if downside == 0 {
+		return 0
+	}
+	return Mean(excess) / downside * math.Sqrt(PeriodsPerYear)
+}
+
+// Beta — наклон доходностей портфеля к доходностям бенчмарка. Ряды должны быть выровнены по датам.
+func Beta(returns, benchmark []float64) float64 {
+	n := len(returns)
+	if len(benchmark) < n {
+		n = len(benchmark)
+	}
+	if n < 2 {
+		return 0
+	}
+	mr, mb := Mean(returns[:n]), Mean(benchmark[:n])
+	var cov, variance float64
+	for i := 0; i < n; i++ {
+		cov += (returns[i] - mr) * (benchmark[i] - mb)
+		variance += (benchmark[i] - mb) * (benchmark[i] - mb)
+	}
+	if variance == 0 {
+		return 0
+	}
+	return cov / variance
+}
+
+func excessReturns(returns []float64, riskFreeRate float64) []float64 {
+	daily := math.Pow(1+riskFreeRate, 1/float64(PeriodsPerYear)) - 1
+	out := make([]float64, len(returns))
+	for i, r := range returns {
+		out[i] = r - daily
+	}
+	return out
+}
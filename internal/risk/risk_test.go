@@ -0,0 +1,154 @@
+package risk
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func equalSlices(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !near(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestReturns(t *testing.T) {
+	tests := []struct {
+		name  string
+		index []float64
+		want  []float64
+	}{
+		{"empty", nil, nil},
+		{"single point", []float64{1}, nil},
+		{"up and down", []float64{100, 110, 99}, []float64{0.1, -0.1}},
+		{"zero base", []float64{0, 1, 2}, []float64{0, 1}},
+	}
+	for _, tt := range tests {
+		if got := Returns(tt.index); !equalSlices(got, tt.want) {
+			t.Errorf("%s: Returns(%v) = %v, want %v", tt.name, tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestMeanStdDev(t *testing.T) {
+	tests := []struct {
+		xs        []float64
+		mean, std float64
+	}{
+		{nil, 0, 0},
+		{[]float64{5}, 5, 0},
+		{[]float64{3, 3, 3}, 3, 0},
+		{[]float64{2, 4, 4, 4, 5, 5, 7, 9}, 5, math.Sqrt(32.0 / 7)},
+	}
+	for _, tt := range tests {
+		if got := Mean(tt.xs); !near(got, tt.mean) {
+			t.Errorf("Mean(%v) = %v, want %v", tt.xs, got, tt.mean)
+		}
+		if got := StdDev(tt.xs); !near(got, tt.std) {
+			t.Errorf("StdDev(%v) = %v, want %v", tt.xs, got, tt.std)
+		}
+	}
+}
+
+func TestAnnualized(t *testing.T) {
+	if got := AnnualizedReturn(0.1, PeriodsPerYear); !near(got, 0.1) {
+		t.Errorf("AnnualizedReturn over a year = %v, want 0.1", got)
+	}
+	if got := AnnualizedReturn(0.21, 2*PeriodsPerYear); !near(got, 0.1) {
+		t.Errorf("AnnualizedReturn over two years = %v, want 0.1", got)
+	}
+	if got := AnnualizedReturn(0.1, 0); got != 0 {
+		t.Errorf("AnnualizedReturn with no periods = %v, want 0", got)
+	}
+	if got := AnnualizedReturn(-1, 10); got != 0 {
+		t.Errorf("AnnualizedReturn after total loss = %v, want 0", got)
+	}
+	if got := AnnualizedVolatility([]float64{0.01, -0.01}); !near(got, math.Sqrt(0.0002)*math.Sqrt(PeriodsPerYear)) {
+		t.Errorf("AnnualizedVolatility = %v", got)
+	}
+}
+
+func TestMaxDrawdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		index    []float64
+		depth    float64
+		duration int
+	}{
+		{"empty", nil, 0, 0},
+		{"single point", []float64{1}, 0, 0},
+		{"only growth", []float64{1, 1.1, 1.2}, 0, 0},
+		{"recovered", []float64{100, 80, 90, 100, 110}, -0.2, 3},
+		{"deepest of two, not recovered", []float64{100, 120, 90, 130, 65, 70}, -0.5, 2},
+		{"shallow but long then deep and short", []float64{100, 95, 95, 95, 95, 101, 50, 101}, 50.0/101 - 1, 2},
+	}
+	for _, tt := range tests {
+		depth, duration := MaxDrawdown(tt.index)
+		if !near(depth, tt.depth) || duration != tt.duration {
+			t.Errorf("%s: MaxDrawdown = (%v, %d), want (%v, %d)", tt.name, depth, duration, tt.depth, tt.duration)
+		}
+	}
+}
+
+func TestSharpeSortino(t *testing.T) {
+	annual := math.Sqrt(PeriodsPerYear)
+	tests := []struct {
+		name            string
+		returns         []float64
+		rate            float64
+		sharpe, sortino float64
+	}{
+		{"empty", nil, 0, 0, 0},
+		{"single point", []float64{0.01}, 0, 0, 0},
+		{"zero variance", []float64{0.01, 0.01, 0.01}, 0, 0, 0},
+		{"zero variance with risk-free rate", []float64{0.001, 0.001, 0.001, 0.001}, 0.16, 0, 0},
+		{"positive", []float64{0.01, 0.03}, 0, 0.02 / math.Sqrt(0.0002) * annual, 0},
+		{"mixed", []float64{0.02, -0.01}, 0, 0.005 / math.Sqrt(0.00045) * annual, 0.005 / math.Sqrt(0.00005) * annual},
+	}
+	for _, tt := range tests {
+		if got := Sharpe(tt.returns, tt.rate); !near(got, tt.sharpe) {
+			t.Errorf("%s: Sharpe = %v, want %v", tt.name, got, tt.sharpe)
+		}
+		if got := Sortino(tt.returns, tt.rate); !near(got, tt.sortino) {
+			t.Errorf("%s: Sortino = %v, want %v", tt.name, got, tt.sortino)
+		}
+	}
+
+	// Безрисковая ставка вычитается из каждой дневной доходности.
+	daily := math.Pow(1.16, 1.0/PeriodsPerYear) - 1
+	returns := []float64{0.01, 0.03}
+	want := (0.02 - daily) / math.Sqrt(0.0002) * annual
+	if got := Sharpe(returns, 0.16); !near(got, want) {
+		t.Errorf("Sharpe with risk-free rate = %v, want %v", got, want)
+	}
+}
+
+func TestBeta(t *testing.T) {
+	tests := []struct {
+		name      string
+		returns   []float64
+		benchmark []float64
+		want      float64
+	}{
+		{"empty", nil, nil, 0},
+		{"single point", []float64{0.01}, []float64{0.02}, 0},
+		{"double the benchmark", []float64{0.02, -0.04, 0.06}, []float64{0.01, -0.02, 0.03}, 2},
+		{"inverse", []float64{-0.01, 0.02}, []float64{0.01, -0.02}, -1},
+		{"flat benchmark", []float64{0.01, 0.02}, []float64{0.01, 0.01}, 0},
+		{"different lengths", []float64{0.02, -0.04, 0.5}, []float64{0.01, -0.02}, 2},
+	}
+	for _, tt := range tests {
+		if got := Beta(tt.returns, tt.benchmark); !near(got, tt.want) {
+			t.Errorf("%s: Beta = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
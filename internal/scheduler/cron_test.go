@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"*/15 * * * *", date(2024, 1, 10, 10, 7), date(2024, 1, 10, 10, 15)},
+		{"*/15 * * * *", date(2024, 1, 10, 10, 45), date(2024, 1, 10, 11, 0)},
+		// Next строго после from.
+		{"0 * * * *", date(2024, 1, 10, 10, 0), date(2024, 1, 10, 11, 0)},
+		{"0 9-17/4 * * *", date(2024, 1, 10, 10, 0), date(2024, 1, 10, 13, 0)},
+		{"0 9-17/4 * * *", date(2024, 1, 10, 17, 0), date(2024, 1, 11, 9, 0)},
+		{"30 8,12 * * *", date(2024, 1, 10, 9, 0), date(2024, 1, 10, 12, 30)},
+		// 13 января 2024 — суббота.
+		{"0 9 * * 1-5", date(2024, 1, 13, 10, 0), date(2024, 1, 15, 9, 0)},
+		{"0 0 * * 7", date(2024, 1, 10, 0, 0), date(2024, 1, 14, 0, 0)},
+		{"0 0 * * 0", date(2024, 1, 10, 0, 0), date(2024, 1, 14, 0, 0)},
+		// Заданы и день месяца, и день недели — достаточно любого: пятница 5-го раньше 13-го.
+		{"0 0 13 * 5", date(2024, 1, 1, 0, 0), date(2024, 1, 5, 0, 0)},
+		{"0 0 13 * 5", date(2024, 1, 12, 0, 0), date(2024, 1, 13, 0, 0)},
+		// */2 в дне месяца считается звёздочкой, поэтому отбор идёт только по понедельникам.
+		{"0 0 */2 * 1", date(2024, 1, 1, 0, 0), date(2024, 1, 8, 0, 0)},
+		{"0 0 1 * */2", date(2024, 1, 2, 0, 0), date(2024, 2, 1, 0, 0)},
+		{"0 0 31 * *", date(2024, 2, 1, 0, 0), date(2024, 3, 31, 0, 0)},
+		{"0 0 29 2 *", date(2024, 3, 1, 0, 0), date(2028, 2, 29, 0, 0)},
+		{"@daily", date(2024, 1, 10, 10, 0), date(2024, 1, 11, 0, 0)},
+		{"@weekly", date(2024, 1, 10, 10, 0), date(2024, 1, 14, 0, 0)},
+		{"@monthly", date(2024, 1, 10, 10, 0), date(2024, 2, 1, 0, 0)},
+		{"@every 5m", date(2024, 1, 10, 10, 7).Add(30 * time.Second), date(2024, 1, 10, 10, 10)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%v) = %v, want %v", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleNeverFires(t *testing.T) {
+	for _, spec := range []string{"0 0 30 2 *", "0 0 31 4 *"} {
+		s, err := Parse(spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", spec, err)
+		}
+		if got := s.Next(date(2024, 1, 1, 0, 0)); !got.IsZero() {
+			t.Errorf("%q.Next = %v, want zero time", spec, got)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-a * * * *",
+		"@every 0s",
+		"@every 5",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", spec)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"tinvest_report/internal/logging"
+	"tinvest_report/internal/metrics"
+)
+
+var tracer = otel.Tracer("tinvest_report/scheduler")
+
+var (
+	ErrUnknownJob = errors.New("задача не найдена")
+	ErrJobRunning = errors.New("задача уже выполняется")
+	ErrLockLost   = errors.New("блокировка задачи потеряна")
+)
+
+// Locker не даёт нескольким репликам выполнять одну задачу одновременно и повторно
+// выполнять один и тот же слот расписания.
+type Locker interface {
+	// Acquire захватывает задачу на слот расписания slot; manual — запуск вне расписания.
+	// Возвращает nil без ошибки, если задачу выполняет или уже выполнила другая реплика.
+	Acquire(ctx context.Context, job string, slot time.Time, manual bool) (Lease, error)
+}
+
+// Lease — захваченный запуск задачи.
+type Lease interface {
+	// Lost закрывается, если блокировка потеряна во время выполнения.
+	Lost() <-chan struct{}
+	// Release записывает результат запуска и освобождает блокировку.
+	Release(runErr error)
+}
+
+// JobFunc выполняет задачу; ctx отменяется при остановке планировщика.
+type JobFunc func(ctx context.Context) error
+
+// JobStatus — состояние задачи для GET /jobs.
+type JobStatus struct {
+	Name         string     `json:"name"`
+	Schedule     string     `json:"schedule"`
+	Running      bool       `json:"running"`
+	LastStart    *time.Time `json:"last_start,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	Runs         int        `json:"runs"`
+	// Skipped — сколько слотов выполнила другая реплика.
+	Skipped int        `json:"skipped"`
+	NextRun *time.Time `json:"next_run,omitempty"`
+}
+
+type job struct {
+	name     string
+	spec     string
+	schedule Schedule
+	timeout  time.Duration
+	fn       JobFunc
+	trigger  chan struct{}
+
+	// Поля ниже защищены Scheduler.mu.
+	running   bool
+	lastStart time.Time
+	lastDur   time.Duration
+	lastErr   string
+	runs      int
+	skipped   int
+	next      time.Time
+}
+
+// Scheduler запускает задачи по расписанию в горутинах процесса. Одна задача
+// не выполняется параллельно сама с собой.
+type Scheduler struct {
+	// Locker, если задан, согласует запуски между репликами.
+	Locker Locker
+
+	mu   sync.Mutex
+	jobs []*job
+	wg   sync.WaitGroup
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add регистрирует задачу. timeout ограничивает один запуск; 0 — без ограничения.
+// Задачи добавляются до Start.
+func (s *Scheduler) Add(name, spec string, timeout time.Duration, fn JobFunc) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("задача %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.name == name {
+			return fmt.Errorf("задача %s уже зарегистрирована", name)
+		}
+	}
+	s.jobs = append(s.jobs, &job{
+		name: name, spec: spec, schedule: schedule, timeout: timeout, fn: fn,
+		trigger: make(chan struct{}, 1),
+	})
+	return nil
+}
+
+// Start запускает задачи; они останавливаются при отмене ctx.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, j)
+	}
+	slog.Info("Планировщик запущен", "jobs", len(s.jobs))
+}
+
+// Wait дожидается завершения выполняющихся задач после отмены контекста Start.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// Trigger запускает задачу вне расписания, не дожидаясь её завершения.
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j := s.find(name)
+	if j == nil {
+		return ErrUnknownJob
+	}
+	if j.running {
+		return ErrJobRunning
+	}
+	select {
+	case j.trigger <- struct{}{}:
+		return nil
+	default:
+		return ErrJobRunning
+	}
+}
+
+// Jobs возвращает состояние задач в порядке регистрации.
+func (s *Scheduler) Jobs() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		st := JobStatus{Name: j.name, Schedule: j.spec, Running: j.running, LastError: j.lastErr, Runs: j.runs, Skipped: j.skipped}
+		if !j.lastStart.IsZero() {
+			start := j.lastStart
+			st.LastStart = &start
+			if !j.running {
+				st.LastDuration = j.lastDur.Round(time.Millisecond).String()
+			}
+		}
+		if !j.next.IsZero() {
+			next := j.next
+			st.NextRun = &next
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+func (s *Scheduler) find(name string) *job {
+	for _, j := range s.jobs {
+		if j.name == name {
+			return j
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	defer s.wg.Done()
+	for {
+		next := j.schedule.Next(time.Now())
+		s.mu.Lock()
+		j.next = next
+		s.mu.Unlock()
+
+		// Расписание, которое никогда не наступает, оставляет только ручной запуск.
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			fire = timer.C
+		}
+		slot, manual := next, false
+		select {
+		case <-ctx.Done():
+		case <-fire:
+		case <-j.trigger:
+			slot, manual = time.Now(), true
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		s.run(ctx, j, slot, manual)
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, j *job, slot time.Time, manual bool) {
+	// Свой ID у каждого запуска связывает записи лога задачи, как ID запроса у HTTP.
+	ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+	start := time.Now()
+	s.mu.Lock()
+	j.running, j.lastStart = true, start
+	s.mu.Unlock()
+
+	skipped, err := s.exclusive(ctx, j, slot, manual)
+	if skipped {
+		s.mu.Lock()
+		j.running, j.skipped = false, j.skipped+1
+		s.mu.Unlock()
+		metrics.JobRuns.WithLabelValues(j.name, "skipped").Inc()
+		slog.InfoContext(ctx, "Задача пропущена: слот выполняет другая реплика", "job", j.name, "slot", slot.Format(time.RFC3339))
+		return
+	}
+
+	s.mu.Lock()
+	j.running, j.lastDur, j.runs = false, time.Since(start), j.runs+1
+	j.lastErr = ""
+	if err != nil {
+		j.lastErr = err.Error()
+	}
+	s.mu.Unlock()
+
+	outcome := "success"
+	switch {
+	case errors.Is(err, ErrLockLost):
+		outcome = "lost"
+	case err != nil:
+		outcome = "failed"
+	}
+	metrics.JobRuns.WithLabelValues(j.name, outcome).Inc()
+	metrics.JobDuration.WithLabelValues(j.name).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Задача завершилась с ошибкой", "job", j.name, "duration", time.Since(start), "error", err)
+		return
+	}
+	slog.DebugContext(ctx, "Задача выполнена", "job", j.name, "manual", manual, "duration", time.Since(start))
+}
+
+// exclusive выполняет задачу под блокировкой Locker; skipped — слот занят другой репликой.
+// При потере блокировки контекст задачи отменяется.
+func (s *Scheduler) exclusive(ctx context.Context, j *job, slot time.Time, manual bool) (skipped bool, err error) {
+	if s.Locker == nil {
+		return false, s.call(ctx, j)
+	}
+	lease, err := s.Locker.Acquire(ctx, j.name, slot, manual)
+	if err != nil {
+		return false, fmt.Errorf("блокировка: %w", err)
+	}
+	if lease == nil {
+		return true, nil
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-lease.Lost():
+			cancel()
+		case <-runCtx.Done():
+		}
+	}()
+
+	err = s.call(runCtx, j)
+	select {
+	case <-lease.Lost():
+		err = ErrLockLost
+	default:
+	}
+	lease.Release(err)
+	return false, err
+}
+
+// call выполняет задачу с таймаутом и превращает панику в ошибку, чтобы не уронить процесс.
+// Запуск оформляется корневым спаном, к которому привязываются запросы к API и БД.
+func (s *Scheduler) call(ctx context.Context, j *job) (err error) {
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+	ctx, span := tracer.Start(ctx, "job "+j.name)
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("паника: %v", r)
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	return j.fn(ctx)
+}
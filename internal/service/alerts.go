@@ -0,0 +1,310 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/repository"
+)
+
+// webhookTimeout — таймаут одной попытки доставки оповещения.
+const webhookTimeout = 10 * time.Second
+
+// deliverySaveTimeout ограничивает запись в журнал доставки, которая выполняется
+// и после отмены контекста доставки.
+const deliverySaveTimeout = 5 * time.Second
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// alertInputs — данные, общие для всех правил одной проверки. Снимки загружаются
+// при первом обращении к ним.
+type alertInputs struct {
+	prices map[string]models.InstrumentPrice
+	// fetched — цены, запрошенные у API во время проверки; сохраняются одним запросом.
+	fetched []models.InstrumentPrice
+	loaded  bool
+	latest  *models.Summary
+	dayAgo  *models.Summary
+}
+
+// EvaluateAlerts проверяет включённые правила по кэшированным ценам и последним
+// снимкам отчёта и отправляет оповещения по сработавшим. Возвращает число срабатываний.
+// Доставка идёт в фоне (см. deliverAlert), поэтому медленный webhook не задерживает проверку.
+func (a *App) EvaluateAlerts(ctx context.Context) (int, error) {
+	rules, err := a.Repo.ListAlertRules(ctx, true)
+	if err != nil || len(rules) == 0 {
+		return 0, err
+	}
+	prices, err := a.Repo.GetInstrumentPrices(ctx)
+	if err != nil {
+		return 0, err
+	}
+	in := &alertInputs{prices: prices}
+	defer a.saveAlertPrices(ctx, in)
+
+	fired := 0
+	for _, rule := range rules {
+		value, ok, err := a.alertValue(ctx, rule, in)
+		if err != nil {
+			return fired, err
+		}
+		if !ok {
+			continue
+		}
+
+		met := alertConditionMet(rule, value)
+		var triggeredAt *time.Time
+		fire := alertFires(rule, met)
+		if fire {
+			now := time.Now()
+			triggeredAt = &now
+		}
+		// Состояние сохраняется до запуска доставки: неудачная доставка сбрасывает его,
+		// и сброс не должен затереться этой записью.
+		if err := a.Repo.SaveAlertState(ctx, rule.ID, met, value, triggeredAt); err != nil {
+			return fired, err
+		}
+		if fire {
+			a.deliverAlert(ctx, rule, value, *triggeredAt)
+			fired++
+		}
+	}
+	return fired, nil
+}
+
+// alertFires сообщает, нужно ли отправить оповещение: условие выполнено, а при прошлой
+// проверке не было выполнено или его оповещение не доставлено (см. notifyAlert).
+func alertFires(rule models.AlertRule, met bool) bool {
+	return met && !rule.Active
+}
+
+// alertValue возвращает проверяемую величину правила; ok = false, если данных пока нет.
+func (a *App) alertValue(ctx context.Context, rule models.AlertRule, in *alertInputs) (float64, bool, error) {
+	switch rule.Type {
+	case models.AlertPriceCross:
+		price, ok := a.alertPrice(ctx, rule.FIGI, in)
+		return price, ok, nil
+	case models.AlertDailyDrop, models.AlertNetProfitCross:
+		if err := a.loadAlertSummaries(ctx, in); err != nil {
+			return 0, false, err
+		}
+		if in.latest == nil {
+			return 0, false, nil
+		}
+		if rule.Type == models.AlertNetProfitCross {
+			return in.latest.NetStockProfit, true, nil
+		}
+		if in.dayAgo == nil || in.dayAgo.PortfolioValue <= 0 {
+			return 0, false, nil
+		}
+		drop := (in.dayAgo.PortfolioValue - in.latest.PortfolioValue) / in.dayAgo.PortfolioValue * 100
+		return drop, true, nil
+	}
+	slog.WarnContext(ctx, "Неизвестный тип правила оповещения", "rule_id", rule.ID, "type", rule.Type)
+	return 0, false, nil
+}
+
+// alertPrice берёт кэшированную цену, а если она устарела — запрашивает новую. Новая цена
+// используется остальными правилами проверки и сохраняется в saveAlertPrices.
+func (a *App) alertPrice(ctx context.Context, figi string, in *alertInputs) (float64, bool) {
+	known := in.prices[figi]
+	if known.LastPrice != nil && known.LastPriceAt != nil && time.Since(*known.LastPriceAt) < a.Alerts.PriceMaxAge {
+		return *known.LastPrice, true
+	}
+	priceData, err := a.Tinkoff.GetFigiPrice(ctx, figi)
+	if err != nil {
+		slog.WarnContext(ctx, "Не удалось обновить цену для оповещений", "figi", figi, "error", err)
+		if known.LastPrice != nil {
+			return *known.LastPrice, true
+		}
+		return 0, false
+	}
+	now := time.Now()
+	known.FIGI, known.Name, known.LastPrice, known.LastPriceAt = figi, priceData.Name, &priceData.Price, &now
+	in.prices[figi] = known
+	in.fetched = append(in.fetched, known)
+	return priceData.Price, true
+}
+
+// saveAlertPrices сохраняет цены, запрошенные во время проверки.
+func (a *App) saveAlertPrices(ctx context.Context, in *alertInputs) {
+	if err := a.Repo.SaveLastKnownPrices(ctx, in.fetched); err != nil {
+		slog.WarnContext(ctx, "Не удалось сохранить цены", "error", err)
+	}
+}
+
+// loadAlertSummaries загружает последний снимок счёта и снимок не позже чем за сутки до него.
+func (a *App) loadAlertSummaries(ctx context.Context, in *alertInputs) error {
+	if in.loaded {
+		return nil
+	}
+	in.loaded = true
+
+	q := repository.SummaryQuery{AccountID: a.Tinkoff.AccountID(), Desc: true, Limit: 1}
+	latest, _, err := a.Repo.GetSummaries(ctx, q)
+	if err != nil || len(latest) == 0 {
+		return err
+	}
+	in.latest = &latest[0]
+
+	dayAgo := in.latest.CreatedAt.Add(-24 * time.Hour).Add(time.Nanosecond)
+	q.To = &dayAgo
+	prev, _, err := a.Repo.GetSummaries(ctx, q)
+	if err != nil || len(prev) == 0 {
+		return err
+	}
+	in.dayAgo = &prev[0]
+	return nil
+}
+
+func alertConditionMet(rule models.AlertRule, value float64) bool {
+	if rule.Type == models.AlertDailyDrop {
+		return value >= rule.Threshold
+	}
+	if rule.Direction == models.AlertBelow {
+		return value <= rule.Threshold
+	}
+	return value >= rule.Threshold
+}
+
+func alertMessage(rule models.AlertRule, value float64) string {
+	side := "выше"
+	if rule.Direction == models.AlertBelow {
+		side = "ниже"
+	}
+	switch rule.Type {
+	case models.AlertPriceCross:
+		return fmt.Sprintf("Цена %s %.4f %s порога %.4f", rule.FIGI, value, side, rule.Threshold)
+	case models.AlertDailyDrop:
+		return fmt.Sprintf("Портфель подешевел за сутки на %.2f%% (порог %.2f%%)", value, rule.Threshold)
+	case models.AlertNetProfitCross:
+		return fmt.Sprintf("Чистая прибыль %.2f %s порога %.2f", value, side, rule.Threshold)
+	}
+	return fmt.Sprintf("Правило %d сработало: %.4f", rule.ID, value)
+}
+
+// deliverAlert запускает notifyAlert в отдельной горутине. Контекст доставки не отменяется
+// вместе с задачей проверки, но ограничен временем всех попыток; дождаться доставок
+// при остановке можно через WaitAlertDeliveries.
+func (a *App) deliverAlert(ctx context.Context, rule models.AlertRule, value float64, at time.Time) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), a.alertDeliveryTimeout())
+	a.deliveries.Add(1)
+	go func() {
+		defer a.deliveries.Done()
+		defer cancel()
+		a.notifyAlert(ctx, rule, value, at)
+	}()
+}
+
+// WaitAlertDeliveries дожидается оповещений, которые ещё доставляются.
+func (a *App) WaitAlertDeliveries() {
+	a.deliveries.Wait()
+}
+
+// alertDeliveryTimeout — время на все попытки доставки с паузами между ними.
+func (a *App) alertDeliveryTimeout() time.Duration {
+	attempts := max(a.Alerts.MaxAttempts, 1)
+	pauses := time.Duration(attempts*(attempts-1)/2) * a.Alerts.RetryDelay
+	return time.Duration(attempts)*webhookTimeout + pauses
+}
+
+// notifyAlert отправляет оповещение на webhook с повторами и записывает результат в журнал доставки.
+// Повторы прекращаются при отмене ctx. Если оповещение не доставлено, состояние правила
+// сбрасывается, и следующая проверка отправит его снова, пока условие выполняется.
+func (a *App) notifyAlert(ctx context.Context, rule models.AlertRule, value float64, at time.Time) {
+	payload, err := json.Marshal(models.AlertNotification{
+		RuleID:      rule.ID,
+		RuleType:    rule.Type,
+		FIGI:        rule.FIGI,
+		Direction:   rule.Direction,
+		Threshold:   rule.Threshold,
+		Value:       value,
+		Message:     alertMessage(rule, value),
+		TriggeredAt: at,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Не удалось сформировать оповещение", "rule_id", rule.ID, "error", err)
+		return
+	}
+
+	webhookURL := rule.WebhookURL
+	if webhookURL == "" {
+		webhookURL = a.Alerts.WebhookURL
+	}
+	delivery := a.sendAlert(ctx, webhookURL, payload)
+	delivery.RuleID = rule.ID
+
+	// Результат записывается и после отмены ctx, поэтому контекст записи отвязан от него.
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deliverySaveTimeout)
+	defer cancel()
+	if delivery.Status == models.AlertDeliverySent {
+		slog.InfoContext(ctx, "Оповещение отправлено", "rule_id", rule.ID)
+	} else {
+		slog.WarnContext(ctx, "Оповещение не доставлено", "rule_id", rule.ID, "error", delivery.Error)
+		if err := a.Repo.ResetAlertState(saveCtx, rule.ID); err != nil {
+			slog.ErrorContext(ctx, "Не удалось сбросить состояние правила", "rule_id", rule.ID, "error", err)
+		}
+	}
+	if err := a.Repo.SaveAlertDelivery(saveCtx, delivery); err != nil {
+		slog.ErrorContext(ctx, "Не удалось записать доставку оповещения", "rule_id", rule.ID, "error", err)
+	}
+}
+
+// sendAlert отправляет payload на webhookURL, повторяя неудачные попытки с растущей паузой,
+// и возвращает запись журнала доставки без ID правила.
+func (a *App) sendAlert(ctx context.Context, webhookURL string, payload []byte) models.AlertDelivery {
+	delivery := models.AlertDelivery{
+		WebhookURL: webhookURL,
+		Payload:    payload,
+		Status:     models.AlertDeliveryFailed,
+	}
+	if webhookURL == "" {
+		delivery.Error = "webhook не настроен"
+		return delivery
+	}
+	for attempt := 1; attempt <= max(a.Alerts.MaxAttempts, 1); attempt++ {
+		delivery.Attempts = attempt
+		code, err := postWebhook(ctx, webhookURL, payload)
+		if code != 0 {
+			delivery.ResponseCode = &code
+		}
+		if err == nil {
+			delivery.Status, delivery.Error = models.AlertDeliverySent, ""
+			return delivery
+		}
+		delivery.Error = err.Error()
+		if attempt < a.Alerts.MaxAttempts {
+			select {
+			case <-ctx.Done():
+				return delivery
+			case <-time.After(a.Alerts.RetryDelay * time.Duration(attempt)):
+			}
+		}
+	}
+	return delivery
+}
+
+func postWebhook(ctx context.Context, url string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook вернул %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tinvest_report/internal/models"
+)
+
+func TestAlertDeliveryTimeout(t *testing.T) {
+	tests := []struct {
+		attempts int
+		delay    time.Duration
+		want     time.Duration
+	}{
+		{1, 5 * time.Second, webhookTimeout},
+		// Паузы растут с номером попытки: 5 с после первой, 10 с после второй.
+		{3, 5 * time.Second, 3*webhookTimeout + 15*time.Second},
+		{0, time.Second, webhookTimeout},
+	}
+	for _, tt := range tests {
+		a := &App{Alerts: models.AlertConfig{MaxAttempts: tt.attempts, RetryDelay: tt.delay}}
+		if got := a.alertDeliveryTimeout(); got != tt.want {
+			t.Errorf("alertDeliveryTimeout(%d, %v) = %v, want %v", tt.attempts, tt.delay, got, tt.want)
+		}
+	}
+}
+
+func TestAlertConditionMet(t *testing.T) {
+	tests := []struct {
+		rule  models.AlertRule
+		value float64
+		want  bool
+	}{
+		{models.AlertRule{Type: models.AlertPriceCross, Direction: models.AlertAbove, Threshold: 100}, 100, true},
+		{models.AlertRule{Type: models.AlertPriceCross, Direction: models.AlertAbove, Threshold: 100}, 99.9, false},
+		{models.AlertRule{Type: models.AlertPriceCross, Direction: models.AlertBelow, Threshold: 100}, 99.9, true},
+		{models.AlertRule{Type: models.AlertPriceCross, Direction: models.AlertBelow, Threshold: 100}, 100.1, false},
+		{models.AlertRule{Type: models.AlertNetProfitCross, Direction: models.AlertBelow, Threshold: -500}, -600, true},
+		// Для daily_drop направление не задаётся: срабатывает падение на порог и больше.
+		{models.AlertRule{Type: models.AlertDailyDrop, Threshold: 5}, 5, true},
+		{models.AlertRule{Type: models.AlertDailyDrop, Threshold: 5}, -7, false},
+	}
+	for _, tt := range tests {
+		if got := alertConditionMet(tt.rule, tt.value); got != tt.want {
+			t.Errorf("alertConditionMet(%s %s %v, %v) = %v, want %v",
+				tt.rule.Type, tt.rule.Direction, tt.rule.Threshold, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAlertFiresOnCrossing(t *testing.T) {
+	// Состояние переходит между проверками так же, как в EvaluateAlerts: active = met.
+	rule := models.AlertRule{Type: models.AlertPriceCross, Direction: models.AlertAbove, Threshold: 100}
+	var fired []float64
+	for _, v := range []float64{90, 105, 110, 120, 95, 101, 102} {
+		met := alertConditionMet(rule, v)
+		if alertFires(rule, met) {
+			fired = append(fired, v)
+		}
+		rule.Active = met
+	}
+	if len(fired) != 2 || fired[0] != 105 || fired[1] != 101 {
+		t.Errorf("fired at %v, want [105 101]: once per crossing", fired)
+	}
+
+	// Недоставленное оповещение сбрасывает active, и правило срабатывает снова.
+	rule.Active = false
+	if !alertFires(rule, true) {
+		t.Error("rule with a failed delivery did not fire again")
+	}
+}
+
+func TestSendAlertRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	a := &App{Alerts: models.AlertConfig{MaxAttempts: 3, RetryDelay: time.Millisecond}}
+	d := a.sendAlert(context.Background(), srv.URL, []byte(`{}`))
+	if d.Status != models.AlertDeliverySent || d.Attempts != 3 || d.Error != "" {
+		t.Errorf("delivery = %+v, want sent on the third attempt", d)
+	}
+	if d.ResponseCode == nil || *d.ResponseCode != http.StatusNoContent {
+		t.Errorf("response code = %v, want 204", d.ResponseCode)
+	}
+
+	calls.Store(-10)
+	d = a.sendAlert(context.Background(), srv.URL, []byte(`{}`))
+	if d.Status != models.AlertDeliveryFailed || d.Attempts != 3 || *d.ResponseCode != http.StatusServiceUnavailable {
+		t.Errorf("delivery = %+v, want failed after 3 attempts", d)
+	}
+
+	d = a.sendAlert(context.Background(), "", []byte(`{}`))
+	if d.Status != models.AlertDeliveryFailed || d.Attempts != 0 || d.Error == "" {
+		t.Errorf("delivery without webhook = %+v", d)
+	}
+}
+
+func TestSendAlertStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		cancel()
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	a := &App{Alerts: models.AlertConfig{MaxAttempts: 5, RetryDelay: time.Hour}}
+	done := make(chan models.AlertDelivery)
+	go func() { done <- a.sendAlert(ctx, srv.URL, []byte(`{}`)) }()
+	select {
+	case d := <-done:
+		if d.Status != models.AlertDeliveryFailed || d.Attempts != 1 || calls.Load() != 1 {
+			t.Errorf("delivery = %+v after %d calls, want one failed attempt", d, calls.Load())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sendAlert kept retrying after the context was canceled")
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"tinvest_report/internal/models"
+)
+
+// allocationUnknown — группа для инструментов, у которых в справочнике нет значения.
+const allocationUnknown = "unknown"
+
+// GetAllocation считает доли позиций по текущим ценам и группирует их по типу инструмента,
+// сектору, валюте, стране риска и меткам. Свободные деньги учитываются как тип cash.
+// Непустой tag ограничивает расчёт бумагами, купленными операциями с этой меткой; деньги
+// относятся ко всему счёту и в такой расчёт не входят. Позиции, оценённые не в валюте
+// отчёта, в доли не входят: курсов валют в сервисе нет, они возвращаются в excluded.
+func (a *App) GetAllocation(ctx context.Context, tag string) (models.Allocation, error) {
+	src, err := a.loadReplaySource(ctx)
+	if err != nil {
+		return models.Allocation{}, err
+	}
+	positions, excluded, cash, err := a.ledgerPositions(ctx, src.ledger(tag))
+	if err != nil {
+		return models.Allocation{}, err
+	}
+	if tag != "" {
+		cash = 0
+	}
+
+	total := cash
+	for _, p := range positions {
+		total += p.Value
+	}
+
+	alloc := models.Allocation{
+		Total:      round2(total),
+		Cash:       round2(cash),
+		Positions:  positions,
+		Excluded:   excluded,
+		Incomplete: len(excluded) > 0,
+	}
+	if total == 0 {
+		return alloc, nil
+	}
+
+	byType := map[string]float64{}
+	bySector := map[string]float64{}
+	byCurrency := map[string]float64{}
+	byCountry := map[string]float64{}
+	for i := range alloc.Positions {
+		p := &alloc.Positions[i]
+		p.Weight = p.Value / total
+		byType[orUnknown(p.Type)] += p.Value
+		bySector[orUnknown(p.Sector)] += p.Value
+		byCurrency[orUnknown(p.Currency)] += p.Value
+		byCountry[orUnknown(p.Country)] += p.Value
+	}
+	if cash > 0 {
+		byType["cash"] += cash
+		byCurrency[a.Config.ReportCurrency] += cash
+	}
+
+	alloc.ByType = allocationSlices(byType, total)
+	alloc.BySector = allocationSlices(bySector, total)
+	alloc.ByCurrency = allocationSlices(byCurrency, total)
+	alloc.ByCountry = allocationSlices(byCountry, total)
+	if tag == "" {
+		alloc.ByTag = allocationSlices(tagValues(src, alloc.Positions), total)
+	}
+	return alloc, nil
+}
+
+// tagValues оценивает бумаги, купленные операциями каждой метки, по ценам positions.
+func tagValues(src *replaySource, positions []models.AllocationPosition) map[string]float64 {
+	prices := make(map[string]float64, len(positions))
+	for _, p := range positions {
+		prices[p.FIGI] = p.Price
+	}
+
+	values := make(map[string]float64)
+	for _, tag := range src.tags() {
+		for figi, qty := range src.ledger(tag).holdings {
+			if v := qty * prices[figi]; v > 0 {
+				values[tag] += v
+			}
+		}
+	}
+	return values
+}
+
+// currentPositions возвращает открытые позиции с текущей стоимостью и справочными данными,
+// позиции не в валюте отчёта и денежный остаток счёта.
+func (a *App) currentPositions(ctx context.Context) ([]models.AllocationPosition, []models.AllocationPosition, float64, error) {
+	l, err := a.buildLedger(ctx, "")
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return a.ledgerPositions(ctx, l)
+}
+
+// ledgerPositions делит позиции на оценённые в валюте отчёта и остальные. Цена инструмента
+// приходит в его валюте, поэтому позиции без справочных данных тоже попадают в excluded:
+// их валюту не удаётся проверить.
+func (a *App) ledgerPositions(ctx context.Context, l *ledger) (positions, excluded []models.AllocationPosition, cash float64, err error) {
+	instruments, _, err := a.valuePositions(ctx, l)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	positions = make([]models.AllocationPosition, 0, len(instruments))
+	for _, inst := range instruments {
+		p := models.AllocationPosition{
+			FIGI:     inst.FIGI,
+			Name:     inst.Name,
+			Quantity: inst.Quantity,
+			Price:    inst.Price,
+			Value:    inst.Value,
+		}
+		info, err := a.Tinkoff.GetInstrumentInfo(ctx, inst.FIGI)
+		if err != nil {
+			slog.WarnContext(ctx, "Не удалось получить справочные данные", "figi", inst.FIGI, "error", err)
+		} else {
+			p.Ticker, p.Type, p.Sector = info.Ticker, info.Type, info.Sector
+			p.Currency, p.Country = info.Currency, info.Country
+		}
+		if !a.inReportCurrency(p.Currency) {
+			slog.WarnContext(ctx, "Позиция не в валюте отчёта, в доли не включена",
+				"figi", p.FIGI, "currency", p.Currency, "report_currency", a.Config.ReportCurrency)
+			excluded = append(excluded, p)
+			continue
+		}
+		positions = append(positions, p)
+	}
+	return positions, excluded, l.cash, nil
+}
+
+func (a *App) inReportCurrency(currency string) bool {
+	return currency != "" && strings.EqualFold(currency, a.Config.ReportCurrency)
+}
+
+func allocationSlices(groups map[string]float64, total float64) []models.AllocationSlice {
+	out := make([]models.AllocationSlice, 0, len(groups))
+	for key, value := range groups {
+		out = append(out, models.AllocationSlice{Key: key, Value: round2(value), Weight: value / total})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Value > out[j].Value })
+	return out
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return allocationUnknown
+	}
+	return s
+}
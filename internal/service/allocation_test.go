@@ -0,0 +1,26 @@
+package service
+
+import (
+	"testing"
+
+	"tinvest_report/internal/config"
+)
+
+func TestInReportCurrency(t *testing.T) {
+	a := &App{Config: &config.Config{ReportCurrency: "rub"}}
+	tests := []struct {
+		currency string
+		want     bool
+	}{
+		{"rub", true},
+		{"RUB", true},
+		{"usd", false},
+		// Без справочных данных валюта неизвестна, такую позицию нельзя смешивать с рублёвыми.
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := a.inReportCurrency(tt.currency); got != tt.want {
+			t.Errorf("inReportCurrency(%q) = %v, want %v", tt.currency, got, tt.want)
+		}
+	}
+}
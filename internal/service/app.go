@@ -1,19 +1,98 @@
 package service
 
 import (
+	"context"
+	"strconv"
+	"sync"
+
+	"tinvest_report/internal/config"
+	"tinvest_report/internal/mailer"
+	"tinvest_report/internal/models"
 	"tinvest_report/internal/repository"
+	"tinvest_report/internal/scheduler"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Version — версия сборки, задаётся через -ldflags "-X tinvest_report/internal/service.Version=...".
+// Сохраняется вместе с отчётами, чтобы было видно, какой код их рассчитал.
+var Version = "dev"
+
 type App struct {
-	Tinkoff *TinkoffClient
-	Repo    *repository.Repository
+	// Config — настройки, с которыми запущено приложение.
+	Config    *config.Config
+	Tinkoff   *TinkoffClient
+	Repo      *repository.Repository
+	Retention models.RetentionPolicy
+	Risk      models.RiskConfig
+	Alerts    models.AlertConfig
+	// Mailer — отправка писем с отчётами; nil, если SMTP не настроен.
+	Mailer *mailer.Mailer
+	// Jobs — фоновые задачи по расписанию; nil, пока планировщик не создан.
+	Jobs *scheduler.Scheduler
+
+	token tokenCache
+	// deliveries — оповещения, которые доставляются в фоне.
+	deliveries sync.WaitGroup
+}
+
+func NewApp(db *pgxpool.Pool, cfg *config.Config) (*App, error) {
+	tinkoff, err := NewTinkoffClient(cfg.Tinkoff)
+	if err != nil {
+		return nil, err
+	}
+	app := &App{
+		Config:    cfg,
+		Tinkoff:   tinkoff,
+		Repo:      repository.NewRepository(db),
+		Retention: cfg.Retention,
+		Risk:      cfg.Risk,
+		Alerts:    cfg.Alerts,
+	}
+	if cfg.SMTP.Host != "" {
+		app.Mailer = mailer.New(cfg.SMTP)
+	}
+	return app, nil
+}
+
+// GetOperations возвращает операции из Tinkoff Invest вместе с ручными операциями из БД
+// и назначенными им метками.
+func (a *App) GetOperations(ctx context.Context) ([]Operation, error) {
+	ops, err := a.Tinkoff.GetOperations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	manual, err := a.Repo.ListManualOperations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range manual {
+		ops = append(ops, manualToOperation(m))
+	}
+
+	tags, err := a.Repo.GetTagAssignments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ops {
+		ops[i].Tags = operationTags(ops[i], tags)
+	}
+	return ops, nil
 }
 
-func NewApp(db *pgxpool.Pool) *App {
-	return &App{
-		Tinkoff: NewTinkoffClient(),
-		Repo:    repository.NewRepository(db),
+func manualToOperation(m models.ManualOperation) Operation {
+	return Operation{
+		ID:           "manual-" + strconv.Itoa(m.ID),
+		Currency:     m.Currency,
+		FloatPayment: m.FloatPayment,
+		Date:         m.Date.Format("02/01/2006"),
+		Type:         m.Type,
+		Operation:    m.OperationType,
+		Figi:         m.FIGI,
+		Quantity:     m.Quantity,
+		Price:        m.Price,
+		IsManual:     true,
+		Time:         m.Date,
 	}
 }
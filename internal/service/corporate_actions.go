@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"tinvest_report/internal/models"
+)
+
+// DetectCorporateActions сравнивает позиции, восстановленные по операциям, с фактическими
+// позициями у брокера и возвращает расхождения. Ничего не сохраняется: расхождение бывает
+// не только из-за сплита, но и из-за пропущенных ручных операций или переводов, поэтому
+// действие добавляет администратор через POST /corporate-actions после проверки.
+func (a *App) DetectCorporateActions(ctx context.Context) ([]models.CorporateActionCandidate, error) {
+	l, err := a.buildLedger(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	positions, err := a.Tinkoff.GetPositions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Бумаги без стоимости приобретения тоже лежат у брокера.
+	expected := make(map[string]float64, len(l.holdings))
+	for figi, qty := range l.holdings {
+		expected[figi] = qty
+	}
+	for figi, qty := range l.missingBasis {
+		expected[figi] += qty
+	}
+	return positionGaps(expected, positions), nil
+}
+
+// positionGaps возвращает инструменты, количество которых по операциям и у брокера различается.
+func positionGaps(expected, actual map[string]float64) []models.CorporateActionCandidate {
+	figis := make(map[string]bool)
+	for figi, qty := range expected {
+		if figi != "" && math.Abs(qty) >= 0.0001 {
+			figis[figi] = true
+		}
+	}
+	for figi, qty := range actual {
+		if figi != "" && math.Abs(qty) >= 0.0001 {
+			figis[figi] = true
+		}
+	}
+
+	candidates := []models.CorporateActionCandidate{}
+	for figi := range figis {
+		c := models.CorporateActionCandidate{FIGI: figi, ExpectedQuantity: expected[figi], ActualQuantity: actual[figi]}
+		if math.Abs(c.ActualQuantity-c.ExpectedQuantity) < 0.0001 {
+			continue
+		}
+		if c.ExpectedQuantity >= 0.0001 && c.ActualQuantity >= 0.0001 {
+			c.Ratio = c.ActualQuantity / c.ExpectedQuantity
+			if isSplitRatio(c.Ratio) {
+				c.SuggestedType = models.CorporateActionSplit
+			}
+		}
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].FIGI < candidates[j].FIGI
+	})
+	return candidates
+}
+
+// isSplitRatio сообщает, похож ли коэффициент на сплит (целое число новых бумаг на одну старую)
+// или обратный сплит (одна новая на целое число старых).
+func isSplitRatio(ratio float64) bool {
+	if ratio < 1 {
+		ratio = 1 / ratio
+	}
+	return ratio >= 2 && math.Abs(ratio-math.Round(ratio)) < 1e-6
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+
+	"tinvest_report/internal/models"
+)
+
+func TestIsSplitRatio(t *testing.T) {
+	tests := []struct {
+		ratio float64
+		want  bool
+	}{
+		{10, true},
+		{2, true},
+		{0.1, true},
+		{0.25, true},
+		{1, false},
+		{1.05, false},
+		{0.96, false},
+		{2.5, false},
+	}
+	for _, tt := range tests {
+		if got := isSplitRatio(tt.ratio); got != tt.want {
+			t.Errorf("isSplitRatio(%v) = %v, want %v", tt.ratio, got, tt.want)
+		}
+	}
+}
+
+func TestPositionGaps(t *testing.T) {
+	got := positionGaps(
+		map[string]float64{"SPLIT": 10, "SAME": 5, "PARTIAL": 100, "SOLD": 3, "": 1},
+		map[string]float64{"SPLIT": 100, "SAME": 5, "PARTIAL": 96, "UNKNOWN": 7},
+	)
+	want := []models.CorporateActionCandidate{
+		{FIGI: "PARTIAL", ExpectedQuantity: 100, ActualQuantity: 96, Ratio: 0.96},
+		{FIGI: "SOLD", ExpectedQuantity: 3},
+		{FIGI: "SPLIT", ExpectedQuantity: 10, ActualQuantity: 100, Ratio: 10, SuggestedType: models.CorporateActionSplit},
+		{FIGI: "UNKNOWN", ActualQuantity: 7},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("positionGaps = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].FIGI != want[i].FIGI || !approx(got[i].ExpectedQuantity, want[i].ExpectedQuantity) ||
+			!approx(got[i].ActualQuantity, want[i].ActualQuantity) || !approx(got[i].Ratio, want[i].Ratio) ||
+			got[i].SuggestedType != want[i].SuggestedType {
+			t.Errorf("gap %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
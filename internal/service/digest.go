@@ -0,0 +1,298 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/repository"
+)
+
+//go:embed templates/digest.html
+var digestFS embed.FS
+
+var digestTemplate = template.Must(template.New("digest.html").Funcs(template.FuncMap{
+	"money": func(v float64) string { return fmt.Sprintf("%.2f ₽", v) },
+}).ParseFS(digestFS, "templates/digest.html"))
+
+// maxDigestMovers — сколько инструментов с наибольшим изменением цены попадает в письмо.
+const maxDigestMovers = 5
+
+// incomeOperations — операции, которые считаются полученным доходом, и их названия в письме.
+var incomeOperations = map[string]string{
+	"OPERATION_TYPE_DIVIDEND": "Дивиденды",
+	"OPERATION_TYPE_COUPON":   "Купон",
+}
+
+var ErrMailerDisabled = errors.New("SMTP не настроен")
+
+// Digest — содержимое письма с отчётом за период.
+type Digest struct {
+	Title       string                     `json:"title"`
+	Frequency   string                     `json:"frequency"`
+	From        time.Time                  `json:"from"`
+	To          time.Time                  `json:"to"`
+	Summary     Summary                    `json:"summary"`
+	Movers      []models.PriceMover        `json:"movers"`
+	Income      []models.IncomeItem        `json:"income"`
+	IncomeTotal float64                    `json:"income_total"`
+	Alerts      []models.AlertNotification `json:"alerts"`
+}
+
+// BuildDigest собирает отчёт, изменения цен с последнего снимка на начало периода,
+// полученные дивиденды и купоны и сработавшие оповещения за сутки или неделю до now.
+func (a *App) BuildDigest(ctx context.Context, frequency string, now time.Time) (Digest, error) {
+	d := Digest{Title: "Ежедневный отчёт по портфелю", Frequency: frequency, From: now.AddDate(0, 0, -1), To: now}
+	if frequency == models.DigestWeekly {
+		d.Title, d.From = "Еженедельный отчёт по портфелю", now.AddDate(0, 0, -7)
+	}
+
+	src, err := a.loadReplaySource(ctx)
+	if err != nil {
+		return d, err
+	}
+	d.Summary, err = a.summarize(ctx, src.ledger(""))
+	if err != nil {
+		return d, err
+	}
+
+	if d.Movers, err = a.digestMovers(ctx, d.From, d.Summary.Instruments); err != nil {
+		return d, err
+	}
+	d.Income, d.IncomeTotal = a.digestIncome(ctx, src.ops, d.From, d.To)
+	if d.Alerts, err = a.digestAlerts(ctx, d.From); err != nil {
+		return d, err
+	}
+	return d, nil
+}
+
+// digestMovers сравнивает текущие цены с ценами последнего снимка не позже from.
+func (a *App) digestMovers(ctx context.Context, from time.Time, current []models.InstrumentSummary) ([]models.PriceMover, error) {
+	to := from.Add(time.Nanosecond)
+	prev, _, err := a.Repo.GetSummaries(ctx, repository.SummaryQuery{
+		AccountID: a.Tinkoff.AccountID(), To: &to, Desc: true, Limit: 1,
+	})
+	if err != nil || len(prev) == 0 {
+		return nil, err
+	}
+
+	prevPrices := make(map[string]float64, len(prev[0].Instruments))
+	for _, inst := range prev[0].Instruments {
+		prevPrices[inst.FIGI] = inst.Price
+	}
+
+	var movers []models.PriceMover
+	for _, inst := range current {
+		was := prevPrices[inst.FIGI]
+		if was <= 0 {
+			continue
+		}
+		movers = append(movers, models.PriceMover{
+			FIGI:      inst.FIGI,
+			Name:      inst.Name,
+			FromPrice: was,
+			Price:     inst.Price,
+			Change:    (inst.Price - was) / was * 100,
+		})
+	}
+	sort.Slice(movers, func(i, j int) bool { return math.Abs(movers[i].Change) > math.Abs(movers[j].Change) })
+	if len(movers) > maxDigestMovers {
+		movers = movers[:maxDigestMovers]
+	}
+	return movers, nil
+}
+
+func (a *App) digestIncome(ctx context.Context, ops []Operation, from, to time.Time) ([]models.IncomeItem, float64) {
+	var items []models.IncomeItem
+	var total float64
+	for _, op := range ops {
+		kind, ok := incomeOperations[op.Operation]
+		if !ok || op.IsCanceled || op.Time.Before(from) || !op.Time.Before(to) {
+			continue
+		}
+		name := op.Figi
+		if info, err := a.Tinkoff.GetInstrumentInfo(ctx, op.Figi); err == nil {
+			name = info.Name
+		}
+		items = append(items, models.IncomeItem{
+			Date: op.Time, FIGI: op.Figi, Name: name, Type: kind, Amount: op.FloatPayment,
+		})
+		total += op.FloatPayment
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Date.Before(items[j].Date) })
+	return items, round2(total)
+}
+
+func (a *App) digestAlerts(ctx context.Context, from time.Time) ([]models.AlertNotification, error) {
+	deliveries, err := a.Repo.GetAlertDeliveriesSince(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	alerts := make([]models.AlertNotification, 0, len(deliveries))
+	for _, d := range deliveries {
+		var n models.AlertNotification
+		if err := json.Unmarshal(d.Payload, &n); err != nil {
+			slog.WarnContext(ctx, "Некорректное тело оповещения", "delivery_id", d.ID, "error", err)
+			continue
+		}
+		alerts = append(alerts, n)
+	}
+	return alerts, nil
+}
+
+// RenderDigest возвращает HTML письма.
+func RenderDigest(d Digest) (string, error) {
+	var buf bytes.Buffer
+	if err := digestTemplate.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// SendDigest собирает и отправляет письмо подписчику вне расписания и записывает результат отправки.
+func (a *App) SendDigest(ctx context.Context, sub models.DigestSubscription, now time.Time) error {
+	if a.Mailer == nil {
+		return ErrMailerDisabled
+	}
+
+	mail := a.renderDigest(ctx, sub.Frequency, now)
+	err := a.sendDigestMail(ctx, sub, mail)
+	var sentAt *time.Time
+	errText := ""
+	if err != nil {
+		errText = err.Error()
+	} else {
+		// last_sent_at хранится без часового пояса, поэтому пишется в UTC.
+		at := now.UTC()
+		sentAt = &at
+	}
+	if saveErr := a.Repo.SaveDigestResult(ctx, sub.ID, sentAt, errText); saveErr != nil {
+		slog.ErrorContext(ctx, "Не удалось записать результат отправки дайджеста", "subscription_id", sub.ID, "error", saveErr)
+	}
+	return err
+}
+
+// digestMail — готовое письмо или ошибка его сборки.
+type digestMail struct {
+	title string
+	html  string
+	err   error
+}
+
+func (a *App) renderDigest(ctx context.Context, frequency string, now time.Time) digestMail {
+	d, err := a.BuildDigest(ctx, frequency, now)
+	if err != nil {
+		return digestMail{err: err}
+	}
+	html, err := RenderDigest(d)
+	return digestMail{title: d.Title, html: html, err: err}
+}
+
+func (a *App) sendDigestMail(ctx context.Context, sub models.DigestSubscription, mail digestMail) error {
+	if mail.err != nil {
+		return mail.err
+	}
+	return a.Mailer.SendHTML(ctx, sub.Email, mail.title, mail.html)
+}
+
+// Повторы неудачной отправки по расписанию: пауза удваивается после каждой неудачи,
+// после maxDigestAttempts попыток письмо ждёт следующего слота подписки.
+const (
+	maxDigestAttempts = 5
+	digestRetryDelay  = 10 * time.Minute
+)
+
+// SendDueDigests отправляет письма подписчикам, у которых наступило время отправки.
+// Письмо собирается один раз на каждую периодичность. Неудачная отправка повторяется
+// с растущей паузой (см. digestDue). Возвращает число отправленных писем.
+func (a *App) SendDueDigests(ctx context.Context, now time.Time) (int, error) {
+	if a.Mailer == nil {
+		return 0, nil
+	}
+	subs, err := a.Repo.ListDigestSubscriptions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	mails := make(map[string]digestMail)
+	sent := 0
+	for _, sub := range subs {
+		if !digestDue(sub, now) {
+			continue
+		}
+		mail, ok := mails[sub.Frequency]
+		if !ok {
+			mail = a.renderDigest(ctx, sub.Frequency, now)
+			mails[sub.Frequency] = mail
+		}
+
+		err := a.sendDigestMail(ctx, sub, mail)
+		failed, errText := 0, ""
+		if err != nil {
+			failed, errText = digestFailures(sub, now)+1, err.Error()
+			if failed >= maxDigestAttempts {
+				slog.ErrorContext(ctx, "Дайджест не отправлен, повторы до следующего слота прекращены",
+					"subscription_id", sub.ID, "attempts", failed, "error", err)
+			} else {
+				slog.WarnContext(ctx, "Не удалось отправить дайджест", "subscription_id", sub.ID, "attempts", failed, "error", err)
+			}
+		}
+		// Время хранится без часового пояса, поэтому пишется в UTC.
+		if saveErr := a.Repo.SaveDigestAttempt(ctx, sub.ID, now.UTC(), errText, failed); saveErr != nil {
+			slog.ErrorContext(ctx, "Не удалось записать результат отправки дайджеста", "subscription_id", sub.ID, "error", saveErr)
+		}
+		if err == nil {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// digestSlot возвращает сегодняшнее время отправки подписки.
+func digestSlot(sub models.DigestSubscription, now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), sub.SendHour, 0, 0, 0, now.Location())
+}
+
+// digestFailures — число неудачных попыток в текущем слоте; неудачи прошлых слотов не считаются.
+func digestFailures(sub models.DigestSubscription, now time.Time) int {
+	if sub.LastAttemptAt == nil || sub.LastAttemptAt.Before(digestSlot(sub, now)) {
+		return 0
+	}
+	return sub.FailedAttempts
+}
+
+// digestDue — наступило ли сегодняшнее время отправки и не было ли письма после него.
+// После неудачи следующая попытка откладывается на digestRetryDelay, 2·digestRetryDelay
+// и так далее, а после maxDigestAttempts неудач — до следующего слота.
+func digestDue(sub models.DigestSubscription, now time.Time) bool {
+	if !sub.Enabled {
+		return false
+	}
+	if sub.Frequency == models.DigestWeekly && int(now.Weekday()) != sub.Weekday {
+		return false
+	}
+	scheduled := digestSlot(sub, now)
+	if now.Before(scheduled) {
+		return false
+	}
+	if sub.LastSentAt != nil && !sub.LastSentAt.Before(scheduled) {
+		return false
+	}
+	failed := digestFailures(sub, now)
+	if failed == 0 {
+		return true
+	}
+	if failed >= maxDigestAttempts {
+		return false
+	}
+	return !now.Before(sub.LastAttemptAt.Add(digestRetryDelay << (failed - 1)))
+}
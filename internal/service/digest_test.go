@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"tinvest_report/internal/models"
+)
+
+func TestDigestDue(t *testing.T) {
+	at := func(hour, minute int) *time.Time {
+		v := time.Date(2024, 1, 10, hour, minute, 0, 0, time.UTC)
+		return &v
+	}
+	yesterday := time.Date(2024, 1, 9, 9, 0, 0, 0, time.UTC)
+	// 10 января 2024 — среда.
+	now := *at(10, 0)
+	daily := models.DigestSubscription{Frequency: models.DigestDaily, SendHour: 9, Enabled: true}
+
+	tests := []struct {
+		name   string
+		change func(s *models.DigestSubscription)
+		now    time.Time
+		want   bool
+	}{
+		{"never sent", func(s *models.DigestSubscription) {}, now, true},
+		{"before send hour", func(s *models.DigestSubscription) {}, *at(8, 59), false},
+		{"disabled", func(s *models.DigestSubscription) { s.Enabled = false }, now, false},
+		{"sent today", func(s *models.DigestSubscription) { s.LastSentAt = at(9, 1) }, now, false},
+		{"sent yesterday", func(s *models.DigestSubscription) { s.LastSentAt = &yesterday }, now, true},
+		{"weekly on another day", func(s *models.DigestSubscription) {
+			s.Frequency, s.Weekday = models.DigestWeekly, int(time.Monday)
+		}, now, false},
+		{"weekly on its day", func(s *models.DigestSubscription) {
+			s.Frequency, s.Weekday = models.DigestWeekly, int(time.Wednesday)
+		}, now, true},
+		// После первой неудачи пауза 10 минут, после третьей — 40.
+		{"first retry too early", func(s *models.DigestSubscription) {
+			s.FailedAttempts, s.LastAttemptAt = 1, at(9, 55)
+		}, now, false},
+		{"first retry", func(s *models.DigestSubscription) {
+			s.FailedAttempts, s.LastAttemptAt = 1, at(9, 50)
+		}, now, true},
+		{"third retry backs off", func(s *models.DigestSubscription) {
+			s.FailedAttempts, s.LastAttemptAt = 3, at(9, 30)
+		}, now, false},
+		{"third retry", func(s *models.DigestSubscription) {
+			s.FailedAttempts, s.LastAttemptAt = 3, at(9, 20)
+		}, now, true},
+		{"attempts exhausted", func(s *models.DigestSubscription) {
+			s.FailedAttempts, s.LastAttemptAt = maxDigestAttempts, at(9, 0)
+		}, *at(23, 0), false},
+		{"failures of the previous slot are ignored", func(s *models.DigestSubscription) {
+			s.FailedAttempts, s.LastAttemptAt = maxDigestAttempts, &yesterday
+		}, now, true},
+	}
+	for _, tt := range tests {
+		sub := daily
+		tt.change(&sub)
+		if got := digestDue(sub, tt.now); got != tt.want {
+			t.Errorf("%s: digestDue = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"tinvest_report/internal/models"
+)
+
+// RebuildEquity восстанавливает дневной ряд капитала счёта (деньги + рыночная стоимость бумаг)
+// от первой операции до сегодняшнего дня по журналу операций и дневным свечам и сохраняет его.
+// Если свечи получить не удалось или бумаге нечем назначить цену, ряд не пересчитывается
+// и сохранённый остаётся прежним: неполные цены дали бы ложные просадки.
+func (a *App) RebuildEquity(ctx context.Context) ([]models.EquityPoint, error) {
+	rp, err := a.newReplay(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accountID := a.Tinkoff.AccountID()
+	if len(rp.ops) == 0 {
+		return nil, a.Repo.ReplaceEquity(ctx, accountID, nil)
+	}
+
+	first := truncateDay(rp.ops[0].Time)
+	today := truncateDay(time.Now())
+
+	closes := make(map[string][]Candle)
+	for _, figi := range heldInstruments(rp) {
+		candles, err := a.Tinkoff.GetDailyCandles(ctx, figi, first, today.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, fmt.Errorf("свечи %s: %w", figi, err)
+		}
+		closes[figi] = candles
+	}
+	known, err := a.Repo.GetInstrumentPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := equitySeries(rp, closes, known, first, today)
+	if err != nil {
+		return nil, err
+	}
+	for i := range points {
+		points[i].AccountID = accountID
+	}
+	if err := a.Repo.ReplaceEquity(ctx, accountID, points); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// equitySeries воспроизводит операции по дням от first до today и оценивает бумаги по цене
+// закрытия дня. До первой свечи берётся цена первой свечи, без свечей — ручная или последняя
+// известная цена; если нет и её, возвращается ошибка. Фьючерсы, как и в heldInstruments,
+// не оцениваются: их результат уже учтён в деньгах.
+func equitySeries(rp *replay, closes map[string][]Candle, known map[string]models.InstrumentPrice, first, today time.Time) ([]models.EquityPoint, error) {
+	lastClose := make(map[string]float64)
+	nextCandle := make(map[string]int)
+	var points []models.EquityPoint
+	var prevEquity, prevDeposits float64
+	index, peak := 1.0, 1.0
+
+	for day := first; !day.After(today); day = day.AddDate(0, 0, 1) {
+		end := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		rp.until(end)
+		l := rp.ledger
+
+		for figi, candles := range closes {
+			i := nextCandle[figi]
+			for ; i < len(candles) && !candles[i].Time.After(end); i++ {
+				lastClose[figi] = candles[i].Close
+			}
+			nextCandle[figi] = i
+		}
+
+		var marketValue float64
+		for figi, qty := range l.holdings {
+			if figi == "" || isFutures(figi) || math.Abs(qty) < 0.0001 {
+				continue
+			}
+			price, ok := lastClose[figi]
+			if !ok && len(closes[figi]) > 0 {
+				price, ok = closes[figi][0].Close, true
+			}
+			if !ok {
+				price, ok = fallbackPrice(known[figi])
+			}
+			if !ok {
+				return nil, fmt.Errorf("нет цены %s на %s: укажите её через PUT /instrument-prices/%s",
+					figi, day.Format("2006-01-02"), figi)
+			}
+			marketValue += qty * price
+		}
+
+		equity := l.cash + marketValue
+		netDeposits := l.totalInput - l.totalOutput
+		flow := netDeposits - prevDeposits
+		if prevEquity > 0 {
+			index *= (equity - flow) / prevEquity
+		}
+		peak = math.Max(peak, index)
+
+		points = append(points, models.EquityPoint{
+			Date:        day,
+			Cash:        round2(l.cash),
+			MarketValue: round2(marketValue),
+			Equity:      round2(equity),
+			NetDeposits: round2(netDeposits),
+			Profit:      round2(equity - netDeposits),
+			TWRIndex:    index,
+			Drawdown:    index/peak - 1,
+		})
+		prevEquity, prevDeposits = equity, netDeposits
+	}
+	return points, nil
+}
+
+// heldInstruments возвращает все FIGI, которые могли быть в портфеле, включая результаты конвертаций.
+func heldInstruments(rp *replay) []string {
+	seen := make(map[string]bool)
+	var figis []string
+	add := func(figi string) {
+		if figi == "" || isFutures(figi) || seen[figi] {
+			return
+		}
+		seen[figi] = true
+		figis = append(figis, figi)
+	}
+	for _, op := range rp.ops {
+		add(op.Figi)
+	}
+	for _, a := range rp.actions {
+		add(a.NewFIGI)
+	}
+	return figis
+}
+
+func fallbackPrice(known models.InstrumentPrice) (float64, bool) {
+	if known.ManualPrice != nil {
+		return *known.ManualPrice, true
+	}
+	if known.LastPrice != nil {
+		return *known.LastPrice, true
+	}
+	return 0, false
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tinvest_report/internal/models"
+)
+
+func TestEquitySeries(t *testing.T) {
+	rp := newReplay([]Operation{
+		op(1, "OPERATION_TYPE_INPUT", "", 0, 1000),
+		op(1, "OPERATION_TYPE_BUY", "AAA", 10, -1000),
+		op(3, "OPERATION_TYPE_INPUT", "", 0, 500),
+	}, nil, nil)
+	closes := map[string][]Candle{"AAA": {
+		{Time: day(2), Close: 110},
+		{Time: day(3), Close: 99},
+	}}
+
+	points, err := equitySeries(rp, closes, nil, truncateDay(day(1)), truncateDay(day(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ equity, twr, drawdown float64 }{
+		// Первый день до первой свечи оценивается по цене первой свечи.
+		{1100, 1, 0},
+		{1100, 1, 0},
+		// Пополнение 500 не меняет доходность: (1490 - 500) / 1100 = 0.9.
+		{1490, 0.9, -0.1},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("got %d points, want %d", len(points), len(want))
+	}
+	for i, w := range want {
+		p := points[i]
+		if !approx(p.Equity, w.equity) || !approx(p.TWRIndex, w.twr) || !approx(p.Drawdown, w.drawdown) {
+			t.Errorf("day %d: equity = %v, twr = %v, drawdown = %v, want %+v", i+1, p.Equity, p.TWRIndex, p.Drawdown, w)
+		}
+	}
+}
+
+func TestEquitySeriesFallbackPrice(t *testing.T) {
+	ops := []Operation{
+		op(1, "OPERATION_TYPE_INPUT", "", 0, 1000),
+		op(1, "OPERATION_TYPE_BUY", "OTC", 10, -1000),
+	}
+	manual := 80.0
+
+	points, err := equitySeries(newReplay(ops, nil, nil), nil,
+		map[string]models.InstrumentPrice{"OTC": {FIGI: "OTC", ManualPrice: &manual}},
+		truncateDay(day(1)), truncateDay(day(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approx(points[1].MarketValue, 800) {
+		t.Errorf("market value = %v, want 800 by manual price", points[1].MarketValue)
+	}
+
+	// Без свечей и известной цены позиция не оценивается нулём: ряд не строится.
+	_, err = equitySeries(newReplay(ops, nil, nil), nil, nil, truncateDay(day(1)), truncateDay(day(2)))
+	if err == nil || !strings.Contains(err.Error(), "OTC") {
+		t.Errorf("error = %v, want missing price for OTC", err)
+	}
+}
+
+func TestTruncateDay(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	got := truncateDay(time.Date(2024, 1, 2, 1, 30, 0, 0, msk))
+	if !got.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("truncateDay = %v, want 2024-01-01 UTC", got)
+	}
+}
+
+func TestEquitySeriesSkipsFutures(t *testing.T) {
+	// Фьючерс, введённый переводом, попадает в holdings, но свечи для него не запрашиваются.
+	ops := []Operation{
+		op(1, "OPERATION_TYPE_INPUT", "", 0, 1000),
+		op(1, "OPERATION_TYPE_INPUT_SECURITIES", "FUTSI0624000", 1, 0),
+	}
+	rp := newReplay(ops, nil, map[string]models.SecurityTransferBasis{
+		"OPERATION_TYPE_INPUT_SECURITIES-FUTSI0624000-1": {Price: 90000},
+	})
+	if figis := heldInstruments(rp); len(figis) != 0 {
+		t.Errorf("heldInstruments = %v, want no futures", figis)
+	}
+	points, err := equitySeries(rp, nil, nil, truncateDay(day(1)), truncateDay(day(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approx(points[1].Equity, 1000) {
+		t.Errorf("equity = %v, want 1000 without the futures position", points[1].Equity)
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/connectivity"
+	"tinvest_report/internal/models"
+)
+
+// tokenCheckTTL — сколько хранится результат проверки токена: /readyz опрашивается часто,
+// а запросы к API Tinkoff ограничены лимитами.
+const tokenCheckTTL = time.Minute
+
+// dependencyTimeout ограничивает каждую проверку, чтобы /readyz отвечал быстрее таймаута пробы.
+const dependencyTimeout = 3 * time.Second
+
+type tokenCache struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	status    models.DependencyStatus
+}
+
+// Readiness проверяет Postgres, gRPC-соединение и токен Tinkoff. Готовность — когда все
+// проверки успешны.
+func (a *App) Readiness(ctx context.Context) models.Readiness {
+	r := models.Readiness{
+		Status: models.HealthOK,
+		Checks: map[string]models.DependencyStatus{
+			"postgres":      a.checkPostgres(ctx),
+			"tinkoff_grpc":  a.checkTinkoffConn(),
+			"tinkoff_token": a.checkTinkoffToken(ctx),
+		},
+		CheckedAt: time.Now(),
+	}
+	for _, c := range r.Checks {
+		if c.Status != models.HealthOK {
+			r.Status = models.HealthFail
+		}
+	}
+
+	if at := a.Tinkoff.LastSync(); !at.IsZero() {
+		r.LastSync = &at
+	}
+	if r.Checks["postgres"].Status == models.HealthOK {
+		ctx, cancel := context.WithTimeout(ctx, dependencyTimeout)
+		defer cancel()
+		r.LastAutosave, _ = a.Repo.LastSummaryAt(ctx, a.Tinkoff.AccountID(), models.SummaryTriggerAuto)
+	}
+	return r
+}
+
+func (a *App) checkPostgres(ctx context.Context) models.DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, dependencyTimeout)
+	defer cancel()
+	start := time.Now()
+	return dependencyResult(ctx, "postgres", start, a.Repo.DB.Ping(ctx))
+}
+
+// checkTinkoffConn считает рабочими и простаивающее, и подключающееся соединение:
+// gRPC переподключается сам при следующем запросе.
+func (a *App) checkTinkoffConn() models.DependencyStatus {
+	state := a.Tinkoff.ConnState()
+	st := models.DependencyStatus{Status: models.HealthOK, Detail: state.String()}
+	if state == connectivity.TransientFailure || state == connectivity.Shutdown {
+		st.Status = models.HealthFail
+	}
+	return st
+}
+
+func (a *App) checkTinkoffToken(ctx context.Context) models.DependencyStatus {
+	a.token.mu.Lock()
+	defer a.token.mu.Unlock()
+	if time.Since(a.token.checkedAt) < tokenCheckTTL {
+		return a.token.status
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dependencyTimeout)
+	defer cancel()
+	start := time.Now()
+	a.token.status = dependencyResult(ctx, "tinkoff_token", start, a.Tinkoff.CheckToken(ctx))
+	a.token.checkedAt = time.Now()
+	return a.token.status
+}
+
+// dependencyResult записывает ошибку проверки в лог, а в ответ отдаёт только её вид:
+// /readyz доступен без ключа, а ошибки подключения содержат адрес и пользователя БД.
+func dependencyResult(ctx context.Context, name string, start time.Time, err error) models.DependencyStatus {
+	st := models.DependencyStatus{Status: models.HealthOK, LatencyMS: time.Since(start).Milliseconds()}
+	if err == nil {
+		return st
+	}
+	slog.WarnContext(ctx, "Проверка готовности не прошла", "dependency", name, "error", err)
+	st.Status, st.Error = models.HealthFail, "недоступен"
+	if errors.Is(err, context.DeadlineExceeded) {
+		st.Error = "таймаут проверки"
+	}
+	return st
+}
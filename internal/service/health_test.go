@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"tinvest_report/internal/models"
+)
+
+func TestDependencyResultHidesError(t *testing.T) {
+	ctx := context.Background()
+	err := errors.New(`failed to connect to host=db.internal user=report database=tinvest: password authentication failed`)
+	st := dependencyResult(ctx, "postgres", time.Now(), err)
+	if st.Status != models.HealthFail || st.Error == "" || strings.Contains(st.Error, "db.internal") {
+		t.Errorf("status = %+v, want a fixed error without connection details", st)
+	}
+
+	st = dependencyResult(ctx, "postgres", time.Now(), fmt.Errorf("ping: %w", context.DeadlineExceeded))
+	if st.Error != "таймаут проверки" {
+		t.Errorf("timeout error = %q", st.Error)
+	}
+
+	if st := dependencyResult(ctx, "postgres", time.Now(), nil); st.Status != models.HealthOK || st.Error != "" {
+		t.Errorf("status = %+v, want ok", st)
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"tinvest_report/internal/models"
+)
+
+var ErrUnknownTicker = errors.New("тикер не найден в операциях счёта")
+
+// InstrumentPnL рассчитывает результат по инструменту с тикером ticker тем же движком,
+// что и общий отчёт, но только по операциям этого инструмента.
+func (a *App) InstrumentPnL(ctx context.Context, ticker string) (models.InstrumentPnL, error) {
+	src, err := a.loadReplaySource(ctx)
+	if err != nil {
+		return models.InstrumentPnL{}, err
+	}
+	info, ok := a.instrumentByTicker(ctx, src.ops, ticker)
+	if !ok {
+		return models.InstrumentPnL{}, ErrUnknownTicker
+	}
+
+	summary, err := a.summarize(ctx, src.ledgerWhere(func(op Operation) bool { return op.Figi == info.FIGI }))
+	if err != nil {
+		return models.InstrumentPnL{}, err
+	}
+
+	pnl := models.InstrumentPnL{
+		FIGI:        info.FIGI,
+		Ticker:      info.Ticker,
+		Name:        info.Name,
+		TotalBuys:   summary.TotalBuys,
+		TotalSells:  summary.TotalSells,
+		Commissions: summary.Commissions,
+		Taxes:       summary.Taxes,
+		NetProfit:   summary.NetStockProfit,
+	}
+	for _, inst := range summary.Instruments {
+		pnl.Quantity += inst.Quantity
+		pnl.Value += inst.Value
+		if inst.FIGI == info.FIGI {
+			pnl.Price = inst.Price
+		}
+	}
+	return pnl, nil
+}
+
+// instrumentByTicker ищет тикер среди инструментов, по которым были операции.
+func (a *App) instrumentByTicker(ctx context.Context, ops []Operation, ticker string) (InstrumentInfo, bool) {
+	seen := make(map[string]bool)
+	for _, op := range ops {
+		if op.Figi == "" || seen[op.Figi] {
+			continue
+		}
+		seen[op.Figi] = true
+
+		info, err := a.Tinkoff.GetInstrumentInfo(ctx, op.Figi)
+		if err != nil {
+			slog.WarnContext(ctx, "Не удалось получить справочные данные", "figi", op.Figi, "error", err)
+			continue
+		}
+		if strings.EqualFold(info.Ticker, ticker) {
+			return info, true
+		}
+	}
+	return InstrumentInfo{}, false
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"sort"
+
+	"tinvest_report/internal/models"
+)
+
+var ErrNoTargets = errors.New("целевые доли не заданы")
+
+// RebalanceOptions — параметры расчёта ребалансировки.
+type RebalanceOptions struct {
+	Dimension string
+	// MinTrade — минимальная сумма сделки, более мелкие сделки не предлагаются.
+	MinTrade float64
+	// Deposit — сумма нового пополнения, которая добавляется к свободным деньгам.
+	Deposit float64
+	// DepositOnly — только покупки на свободные деньги и пополнение, без продаж.
+	DepositOnly bool
+}
+
+type rebalanceCandidate struct {
+	position     models.AllocationPosition
+	lot          float64
+	target       float64
+	targetWeight float64
+	// skip — позиция из группы unknown без своей цели: её группа не известна, поэтому
+	// цель 0 не ставится и сделки по ней не предлагаются.
+	skip bool
+}
+
+// Rebalance сравнивает текущие позиции с целевыми долями и возвращает сделки в лотах.
+// Для групп (тип, сектор, валюта, страна) целевая стоимость группы распределяется
+// между её позициями пропорционально их текущей стоимости. Позиции без цели
+// считаются целью 0, кроме позиций без справочных данных в групповом режиме,
+// остаток весов до 1 остаётся в деньгах. Сначала учитываются продажи, затем
+// покупки от самых недовзвешенных, пока хватает денег.
+func (a *App) Rebalance(ctx context.Context, opts RebalanceOptions) (models.RebalancePlan, error) {
+	targets, err := a.Repo.GetAllocationTargets(ctx, opts.Dimension)
+	if err != nil {
+		return models.RebalancePlan{}, err
+	}
+	if len(targets) == 0 {
+		return models.RebalancePlan{}, ErrNoTargets
+	}
+
+	positions, excluded, cash, err := a.currentPositions(ctx)
+	if err != nil {
+		return models.RebalancePlan{}, err
+	}
+	cash += opts.Deposit
+	total := cash
+	candidates := make(map[string]*rebalanceCandidate, len(positions))
+	for _, p := range positions {
+		total += p.Value
+		candidates[p.FIGI] = &rebalanceCandidate{position: p}
+	}
+
+	plan := models.RebalancePlan{
+		Dimension:  opts.Dimension,
+		Total:      round2(total),
+		CashBefore: round2(cash),
+	}
+	for _, p := range excluded {
+		plan.Excluded = append(plan.Excluded, p.FIGI)
+	}
+
+	if opts.Dimension == models.TargetByFIGI {
+		for _, t := range targets {
+			if _, ok := candidates[t.Key]; ok {
+				continue
+			}
+			price, err := a.Tinkoff.GetFigiPrice(ctx, t.Key)
+			if err != nil {
+				slog.WarnContext(ctx, "Не удалось получить цену для ребалансировки", "figi", t.Key, "error", err)
+				continue
+			}
+			candidates[t.Key] = &rebalanceCandidate{position: models.AllocationPosition{FIGI: t.Key, Name: price.Name, Price: price.Price}}
+		}
+	}
+	plan.Unallocated = assignTargets(candidates, targets, opts.Dimension, total)
+
+	for _, figi := range sortedFIGIs(candidates) {
+		c := candidates[figi]
+		info, err := a.Tinkoff.GetInstrumentInfo(ctx, figi)
+		if err != nil {
+			slog.WarnContext(ctx, "Не удалось получить лотность", "figi", figi, "error", err)
+			delete(candidates, figi)
+			continue
+		}
+		if !a.inReportCurrency(info.Currency) {
+			slog.WarnContext(ctx, "Инструмент не в валюте отчёта, в ребалансировку не включён", "figi", figi, "currency", info.Currency)
+			plan.Excluded = append(plan.Excluded, figi)
+			delete(candidates, figi)
+			continue
+		}
+		if c.position.Ticker == "" {
+			c.position.Ticker = info.Ticker
+		}
+		c.lot = math.Max(float64(info.Lot), 1)
+	}
+
+	plan.Trades, plan.CashAfter = planTrades(candidates, cash, total, opts)
+	return plan, nil
+}
+
+// assignTargets задаёт кандидатам целевую стоимость и возвращает цели, для которых
+// нет ни одной позиции.
+func assignTargets(candidates map[string]*rebalanceCandidate, targets []models.AllocationTarget, dimension string, total float64) []string {
+	var unallocated []string
+	if dimension == models.TargetByFIGI {
+		for _, t := range targets {
+			c, ok := candidates[t.Key]
+			if !ok {
+				unallocated = append(unallocated, t.Key)
+				continue
+			}
+			c.target = t.Weight * total
+			c.targetWeight = t.Weight
+		}
+		return unallocated
+	}
+
+	groups := make(map[string][]*rebalanceCandidate)
+	for _, c := range candidates {
+		key := orUnknown(groupKey(c.position, dimension))
+		groups[key] = append(groups[key], c)
+	}
+	targeted := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		targeted[t.Key] = true
+		members := groups[t.Key]
+		if len(members) == 0 {
+			unallocated = append(unallocated, t.Key)
+			continue
+		}
+		var groupValue float64
+		for _, c := range members {
+			groupValue += c.position.Value
+		}
+		for _, c := range members {
+			share := 1 / float64(len(members))
+			if groupValue > 0 {
+				share = c.position.Value / groupValue
+			}
+			c.target = t.Weight * total * share
+			c.targetWeight = t.Weight * share
+		}
+	}
+	if !targeted[allocationUnknown] {
+		for _, c := range groups[allocationUnknown] {
+			c.skip = true
+		}
+	}
+	return unallocated
+}
+
+// planTrades переводит разницу между целью и текущей стоимостью в сделки целыми лотами.
+// Продажи идут первыми и пополняют доступные деньги, затем покупки от самых
+// недовзвешенных позиций, пока хватает денег.
+func planTrades(candidates map[string]*rebalanceCandidate, cash, total float64, opts RebalanceOptions) ([]models.RebalanceTrade, float64) {
+	var sells, buys []models.RebalanceTrade
+	for _, figi := range sortedFIGIs(candidates) {
+		c := candidates[figi]
+		if c.skip {
+			continue
+		}
+		lotValue := c.position.Price * c.lot
+		if lotValue <= 0 {
+			continue
+		}
+		lots := int64((c.target - c.position.Value) / lotValue)
+		if lots == 0 || math.Abs(float64(lots))*lotValue < opts.MinTrade {
+			continue
+		}
+		if lots < 0 && opts.DepositOnly {
+			continue
+		}
+
+		trade := c.trade(lots, total)
+		if lots < 0 {
+			sells = append(sells, trade)
+		} else {
+			buys = append(buys, trade)
+		}
+	}
+
+	var trades []models.RebalanceTrade
+	available := cash
+	for _, t := range sells {
+		available += t.Value
+		trades = append(trades, t)
+	}
+
+	sort.SliceStable(buys, func(i, j int) bool {
+		return buys[i].TargetWeight-buys[i].CurrentWeight > buys[j].TargetWeight-buys[j].CurrentWeight
+	})
+	for _, t := range buys {
+		if t.Value > available {
+			c := candidates[t.FIGI]
+			lots := int64(available / (c.position.Price * c.lot))
+			if lots == 0 {
+				continue
+			}
+			t = c.trade(lots, total)
+			if t.Value < opts.MinTrade {
+				continue
+			}
+		}
+		available -= t.Value
+		trades = append(trades, t)
+	}
+	return trades, round2(available)
+}
+
+func sortedFIGIs(candidates map[string]*rebalanceCandidate) []string {
+	figis := make([]string, 0, len(candidates))
+	for figi := range candidates {
+		figis = append(figis, figi)
+	}
+	sort.Strings(figis)
+	return figis
+}
+
+func (c *rebalanceCandidate) trade(lots int64, total float64) models.RebalanceTrade {
+	action := "buy"
+	if lots < 0 {
+		action = "sell"
+		lots = -lots
+	}
+	quantity := float64(lots) * c.lot
+	return models.RebalanceTrade{
+		FIGI:          c.position.FIGI,
+		Ticker:        c.position.Ticker,
+		Name:          c.position.Name,
+		Action:        action,
+		Lots:          lots,
+		Quantity:      quantity,
+		Price:         c.position.Price,
+		Value:         round2(quantity * c.position.Price),
+		CurrentWeight: c.position.Value / total,
+		TargetWeight:  c.targetWeight,
+	}
+}
+
+func groupKey(p models.AllocationPosition, dimension string) string {
+	switch dimension {
+	case models.TargetByType:
+		return p.Type
+	case models.TargetBySector:
+		return p.Sector
+	case models.TargetByCurrency:
+		return p.Currency
+	case models.TargetByCountry:
+		return p.Country
+	}
+	return p.FIGI
+}
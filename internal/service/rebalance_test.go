@@ -0,0 +1,166 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"tinvest_report/internal/models"
+)
+
+func candidate(figi, typ string, price, lot, quantity float64) *rebalanceCandidate {
+	return &rebalanceCandidate{
+		position: models.AllocationPosition{FIGI: figi, Type: typ, Price: price, Quantity: quantity, Value: price * quantity},
+		lot:      lot,
+	}
+}
+
+type tradeSummary struct {
+	FIGI   string
+	Action string
+	Lots   int64
+}
+
+func summarize(trades []models.RebalanceTrade) []tradeSummary {
+	var out []tradeSummary
+	for _, t := range trades {
+		out = append(out, tradeSummary{t.FIGI, t.Action, t.Lots})
+	}
+	return out
+}
+
+func TestPlanTrades(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates func() map[string]*rebalanceCandidate
+		targets    []models.AllocationTarget
+		cash       float64
+		opts       RebalanceOptions
+		want       []tradeSummary
+		cashAfter  float64
+	}{
+		{
+			// Цель 3500 при лоте в 10 бумаг по 100 округляется вниз до трёх лотов.
+			name: "lot rounding",
+			candidates: func() map[string]*rebalanceCandidate {
+				return map[string]*rebalanceCandidate{"A": candidate("A", "", 100, 10, 0)}
+			},
+			targets:   []models.AllocationTarget{{Key: "A", Weight: 0.35}},
+			cash:      10000,
+			want:      []tradeSummary{{"A", "buy", 3}},
+			cashAfter: 7000,
+		},
+		{
+			name: "min trade",
+			candidates: func() map[string]*rebalanceCandidate {
+				return map[string]*rebalanceCandidate{"A": candidate("A", "", 100, 10, 0)}
+			},
+			targets:   []models.AllocationTarget{{Key: "A", Weight: 0.35}},
+			cash:      10000,
+			opts:      RebalanceOptions{MinTrade: 5000},
+			cashAfter: 10000,
+		},
+		{
+			// Денег нет, покупка B оплачивается продажей A, поэтому продажа идёт первой.
+			name: "sells before buys",
+			candidates: func() map[string]*rebalanceCandidate {
+				return map[string]*rebalanceCandidate{
+					"A": candidate("A", "", 100, 1, 50),
+					"B": candidate("B", "", 100, 1, 0),
+				}
+			},
+			targets:   []models.AllocationTarget{{Key: "A", Weight: 0}, {Key: "B", Weight: 1}},
+			want:      []tradeSummary{{"A", "sell", 50}, {"B", "buy", 50}},
+			cashAfter: 0,
+		},
+		{
+			name: "deposit only",
+			candidates: func() map[string]*rebalanceCandidate {
+				return map[string]*rebalanceCandidate{
+					"A": candidate("A", "", 100, 1, 50),
+					"B": candidate("B", "", 100, 1, 0),
+				}
+			},
+			targets: []models.AllocationTarget{{Key: "A", Weight: 0}, {Key: "B", Weight: 1}},
+			cash:    1000,
+			opts:    RebalanceOptions{DepositOnly: true},
+			// Без продаж на покупку B есть только 1000.
+			want:      []tradeSummary{{"B", "buy", 10}},
+			cashAfter: 0,
+		},
+		{
+			name: "buys ordered by underweight",
+			candidates: func() map[string]*rebalanceCandidate {
+				return map[string]*rebalanceCandidate{
+					"A": candidate("A", "", 100, 1, 0),
+					"B": candidate("B", "", 100, 1, 0),
+				}
+			},
+			targets:   []models.AllocationTarget{{Key: "A", Weight: 0.2}, {Key: "B", Weight: 0.6}},
+			cash:      1000,
+			want:      []tradeSummary{{"B", "buy", 6}, {"A", "buy", 2}},
+			cashAfter: 200,
+		},
+	}
+	for _, tt := range tests {
+		candidates := tt.candidates()
+		total := tt.cash
+		for _, c := range candidates {
+			total += c.position.Value
+		}
+		assignTargets(candidates, tt.targets, models.TargetByFIGI, total)
+		trades, cashAfter := planTrades(candidates, tt.cash, total, tt.opts)
+		if got := summarize(trades); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: trades = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if !approx(cashAfter, tt.cashAfter) {
+			t.Errorf("%s: cash after = %v, want %v", tt.name, cashAfter, tt.cashAfter)
+		}
+	}
+}
+
+func TestAssignTargetsGroups(t *testing.T) {
+	newCandidates := func() map[string]*rebalanceCandidate {
+		return map[string]*rebalanceCandidate{
+			"A": candidate("A", "share", 100, 1, 30),
+			"B": candidate("B", "share", 100, 1, 10),
+			"C": candidate("C", "bond", 100, 1, 40),
+			// Справочных данных нет — позиция попадает в группу unknown.
+			"D": candidate("D", "", 100, 1, 20),
+		}
+	}
+	const cash, total = 2000, 12000
+	targets := []models.AllocationTarget{{Key: "share", Weight: 0.5}, {Key: "bond", Weight: 0.3}, {Key: "etf", Weight: 0.1}}
+
+	candidates := newCandidates()
+	unallocated := assignTargets(candidates, targets, models.TargetByType, total)
+	if !reflect.DeepEqual(unallocated, []string{"etf"}) {
+		t.Errorf("unallocated = %v, want [etf]", unallocated)
+	}
+	// Цель группы делится между позициями пропорционально их текущей стоимости.
+	if !approx(candidates["A"].target, 4500) || !approx(candidates["B"].target, 1500) || !approx(candidates["C"].target, 3600) {
+		t.Errorf("targets: A = %v, B = %v, C = %v", candidates["A"].target, candidates["B"].target, candidates["C"].target)
+	}
+	if !candidates["D"].skip {
+		t.Error("position from unknown group without a target must be skipped")
+	}
+
+	trades, cashAfter := planTrades(candidates, cash, total, RebalanceOptions{})
+	want := []tradeSummary{{"C", "sell", 4}, {"A", "buy", 15}, {"B", "buy", 5}}
+	if got := summarize(trades); !reflect.DeepEqual(got, want) {
+		t.Errorf("trades = %+v, want %+v", got, want)
+	}
+	if !approx(cashAfter, 400) {
+		t.Errorf("cash after = %v, want 400", cashAfter)
+	}
+
+	// Явная цель для unknown применяется как к любой другой группе.
+	candidates = newCandidates()
+	assignTargets(candidates, append(targets, models.AllocationTarget{Key: allocationUnknown, Weight: 0}), models.TargetByType, total)
+	if candidates["D"].skip {
+		t.Error("unknown group with an explicit target must not be skipped")
+	}
+	trades, _ = planTrades(candidates, cash, total, RebalanceOptions{})
+	if got := summarize(trades); len(got) < 2 || got[0] != (tradeSummary{"C", "sell", 4}) || got[1] != (tradeSummary{"D", "sell", 20}) {
+		t.Errorf("trades = %+v, want sells of C and D first", got)
+	}
+}
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"tinvest_report/internal/logging"
+	"tinvest_report/internal/metrics"
+	"tinvest_report/internal/models"
+)
+
+type Summary struct {
+	TotalInput     float64    `json:"total_input"`
+	TotalOutput    float64    `json:"total_output"`
+	Turnover       float64    `json:"turnover"`
+	TotalBuys      float64    `json:"total_buys"`
+	TotalSells     float64    `json:"total_sells"`
+	PortfolioValue float64    `json:"portfolio_value"`
+	Commissions    float64    `json:"commissions"`
+	Taxes          float64    `json:"taxes"`
+	NetStockProfit float64    `json:"net_stock_profit"`
+	AccountID      string     `json:"account_id"`
+	PeriodFrom     *time.Time `json:"period_from"`
+	PeriodTo       *time.Time `json:"period_to"`
+	Currency       string     `json:"currency"`
+	AppVersion     string     `json:"app_version"`
+	Tag            string     `json:"tag,omitempty"`
+	// Incomplete — есть введённые бумаги без стоимости приобретения (их операции в MissingCostBasis):
+	// пока стоимость не указана, они не учитываются ни в стоимости портфеля, ни в прибыли.
+	Incomplete       bool                       `json:"incomplete"`
+	MissingCostBasis []string                   `json:"missing_cost_basis,omitempty"`
+	Instruments      []models.InstrumentSummary `json:"instruments"`
+
+	// livePrices — цены, полученные из API при расчёте; сохраняются только со снимком.
+	livePrices []models.InstrumentPrice
+}
+
+// ledger — состояние счёта после воспроизведения операций.
+// Бумаги, введённые от другого брокера, учитываются в totalBuys по цене приобретения,
+// выведенные — в totalSells по указанной оценке или по стоимости списанных лотов.
+// cash — сумма всех платежей по операциям, то есть денежный остаток на счёте.
+// Бумаги, введённые без стоимости приобретения, хранятся отдельно в missingBasis и не входят
+// в holdings и lots; продажа таких бумаг не попадает в totalSells.
+type ledger struct {
+	totalInput, totalOutput, turnover float64
+	totalBuys, totalSells             float64
+	commissions, taxes                float64
+	cash                              float64
+	firstOperation                    time.Time
+	holdings                          map[string]float64
+	lots                              map[string][]lot
+	delisted                          map[string]bool
+	missingBasis                      map[string]float64
+	missingBasisOps                   []string
+}
+
+// lot — партия бумаг, купленная или введённая по одной цене.
+type lot struct {
+	quantity   float64
+	price      float64
+	acquiredAt time.Time
+}
+
+// BuildSummary рассчитывает отчёт по всем операциям счёта с учётом ручных операций,
+// корпоративных действий и текущих цен. Непустой tag ограничивает отчёт операциями с этой меткой.
+func (a *App) BuildSummary(ctx context.Context, tag string) (Summary, error) {
+	l, err := a.buildLedger(ctx, tag)
+	if err != nil {
+		return Summary{}, err
+	}
+	summary, err := a.summarize(ctx, l)
+	summary.Tag = tag
+	if err == nil && tag == "" {
+		// ID счёта в метках маскируется так же, как в логах и /config.
+		account := logging.MaskAccountID(summary.AccountID)
+		metrics.PortfolioValue.WithLabelValues(account).Set(summary.PortfolioValue)
+		metrics.NetProfit.WithLabelValues(account).Set(summary.NetStockProfit)
+	}
+	return summary, err
+}
+
+func (a *App) summarize(ctx context.Context, l *ledger) (Summary, error) {
+	instruments, livePrices, err := a.valuePositions(ctx, l)
+	if err != nil {
+		return Summary{}, err
+	}
+	var portfolioValue float64
+	for _, inst := range instruments {
+		portfolioValue += inst.Quantity * inst.Price
+	}
+
+	netProfit := (l.totalSells + portfolioValue) - l.totalBuys - l.commissions - l.taxes
+
+	now := time.Now()
+	var periodFrom *time.Time
+	if !l.firstOperation.IsZero() {
+		periodFrom = &l.firstOperation
+	}
+
+	return Summary{
+		TotalInput:       round2(l.totalInput),
+		TotalOutput:      round2(l.totalOutput),
+		Turnover:         round2(l.turnover),
+		TotalBuys:        round2(l.totalBuys),
+		TotalSells:       round2(l.totalSells),
+		PortfolioValue:   round2(portfolioValue),
+		Commissions:      round2(l.commissions),
+		Taxes:            round2(l.taxes),
+		NetStockProfit:   round2(netProfit),
+		AccountID:        a.Tinkoff.AccountID(),
+		PeriodFrom:       periodFrom,
+		PeriodTo:         &now,
+		Currency:         a.Config.ReportCurrency,
+		AppVersion:       Version,
+		Incomplete:       len(l.missingBasisOps) > 0,
+		MissingCostBasis: l.missingBasisOps,
+		Instruments:      instruments,
+		livePrices:       livePrices,
+	}, nil
+}
+
+// SaveSnapshot рассчитывает отчёт на сервере и сохраняет его одной записью. Заодно обновляются
+// последние известные цены: чтение отчёта их не пишет, чтобы GET не обращался к БД на запись.
+func (a *App) SaveSnapshot(ctx context.Context, trigger string) (models.Summary, error) {
+	summary, err := a.BuildSummary(ctx, "")
+	if err != nil {
+		return models.Summary{}, err
+	}
+	if err := a.Repo.SaveLastKnownPrices(ctx, summary.livePrices); err != nil {
+		slog.WarnContext(ctx, "Не удалось сохранить цены", "error", err)
+	}
+	if summary.Incomplete {
+		slog.WarnContext(ctx, "Снимок неполный: не указана стоимость приобретения введённых бумаг",
+			"operations", summary.MissingCostBasis)
+	}
+
+	return a.Repo.SaveSummary(ctx, models.Summary{
+		TotalInput:     summary.TotalInput,
+		TotalOutput:    summary.TotalOutput,
+		Turnover:       summary.Turnover,
+		TotalBuys:      summary.TotalBuys,
+		TotalSells:     summary.TotalSells,
+		PortfolioValue: summary.PortfolioValue,
+		Commissions:    summary.Commissions,
+		Taxes:          summary.Taxes,
+		NetStockProfit: summary.NetStockProfit,
+		AccountID:      summary.AccountID,
+		PeriodFrom:     summary.PeriodFrom,
+		PeriodTo:       summary.PeriodTo,
+		Currency:       summary.Currency,
+		Trigger:        trigger,
+		AppVersion:     summary.AppVersion,
+		Instruments:    summary.Instruments,
+	})
+}
+
+func (a *App) buildLedger(ctx context.Context, tag string) (*ledger, error) {
+	src, err := a.loadReplaySource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return src.ledger(tag), nil
+}
+
+func (a *App) newReplay(ctx context.Context) (*replay, error) {
+	src, err := a.loadReplaySource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return src.replay(""), nil
+}
+
+// replaySource — исходные данные воспроизведения. Загружаются один раз, чтобы
+// воспроизвести счёт по нескольким меткам без повторных запросов к API.
+type replaySource struct {
+	ops     []Operation
+	actions []models.CorporateAction
+	bases   map[string]models.SecurityTransferBasis
+}
+
+func (a *App) loadReplaySource(ctx context.Context) (*replaySource, error) {
+	ops, err := a.GetOperations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	actions, err := a.Repo.ListCorporateActions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bases, err := a.Repo.GetSecurityTransferBases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &replaySource{ops: ops, actions: actions, bases: bases}, nil
+}
+
+// replay воспроизводит только операции с меткой tag (см. filterByTag).
+func (s *replaySource) replay(tag string) *replay {
+	return newReplay(filterByTag(s.ops, tag), s.actions, s.bases)
+}
+
+// ledger возвращает состояние счёта на текущий момент по операциям с меткой tag.
+func (s *replaySource) ledger(tag string) *ledger {
+	rp := s.replay(tag)
+	rp.until(time.Now())
+	return rp.ledger
+}
+
+// ledgerWhere возвращает состояние счёта на текущий момент по операциям, для которых keep истинно.
+func (s *replaySource) ledgerWhere(keep func(Operation) bool) *ledger {
+	var ops []Operation
+	for _, op := range s.ops {
+		if keep(op) {
+			ops = append(ops, op)
+		}
+	}
+	rp := newReplay(ops, s.actions, s.bases)
+	rp.until(time.Now())
+	return rp.ledger
+}
+
+// replay воспроизводит операции в хронологическом порядке, применяя корпоративные
+// действия (отсортированные по дате) в момент их вступления в силу. Состояние можно
+// продвигать по частям, например по дням.
+type replay struct {
+	ledger  *ledger
+	ops     []Operation
+	actions []models.CorporateAction
+	// bases — стоимость переводов бумаг по ID операции.
+	bases      map[string]models.SecurityTransferBasis
+	nextOp     int
+	nextAction int
+}
+
+func newReplay(ops []Operation, actions []models.CorporateAction, bases map[string]models.SecurityTransferBasis) *replay {
+	sorted := make([]Operation, len(ops))
+	copy(sorted, ops)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	return &replay{
+		ledger: &ledger{
+			holdings:     make(map[string]float64),
+			lots:         make(map[string][]lot),
+			delisted:     make(map[string]bool),
+			missingBasis: make(map[string]float64),
+		},
+		ops:     sorted,
+		actions: actions,
+		bases:   bases,
+	}
+}
+
+// until применяет все операции и корпоративные действия не позже t.
+func (rp *replay) until(t time.Time) {
+	l := rp.ledger
+	for ; rp.nextOp < len(rp.ops) && !rp.ops[rp.nextOp].Time.After(t); rp.nextOp++ {
+		op := rp.ops[rp.nextOp]
+		rp.applyActions(op.Time)
+
+		if l.firstOperation.IsZero() || op.Time.Before(l.firstOperation) {
+			l.firstOperation = op.Time
+		}
+		if op.IsCanceled {
+			continue
+		}
+		l.applyOperation(op, rp.bases)
+	}
+	rp.applyActions(t)
+}
+
+func (rp *replay) applyActions(t time.Time) {
+	for ; rp.nextAction < len(rp.actions) && !rp.actions[rp.nextAction].EffectiveDate.After(t); rp.nextAction++ {
+		rp.ledger.applyCorporateAction(rp.actions[rp.nextAction])
+	}
+}
+
+// isFutures сообщает, что FIGI относится к фьючерсу. Фьючерсы не входят в holdings и лоты:
+// их результат приходит вариационной маржой в cash.
+func isFutures(figi string) bool {
+	return strings.HasPrefix(figi, "FUT")
+}
+
+func (l *ledger) applyOperation(op Operation, bases map[string]models.SecurityTransferBasis) {
+	l.cash += op.FloatPayment
+
+	switch op.Operation {
+	case "OPERATION_TYPE_INPUT", "OPERATION_TYPE_INP_MULTI":
+		l.totalInput += op.FloatPayment
+	case "OPERATION_TYPE_OUTPUT", "OPERATION_TYPE_OUT_MULTI":
+		l.totalOutput += -op.FloatPayment
+	case "OPERATION_TYPE_BUY":
+		if !isFutures(op.Figi) {
+			l.totalBuys += -op.FloatPayment
+			l.turnover += -op.FloatPayment
+			l.holdings[op.Figi] += op.Quantity
+			if op.Quantity > 0 {
+				l.addLot(op.Figi, op.Quantity, -op.FloatPayment/op.Quantity, op.Time)
+			}
+		}
+	case "OPERATION_TYPE_SELL":
+		if !isFutures(op.Figi) {
+			l.turnover += op.FloatPayment
+			qty := op.Quantity - l.takeMissingBasis(op.Figi, op.Quantity)
+			if op.Quantity > 0 {
+				l.totalSells += op.FloatPayment * qty / op.Quantity
+			}
+			l.holdings[op.Figi] -= qty
+			l.takeLots(op.Figi, qty)
+		}
+	case "OPERATION_TYPE_INPUT_SECURITIES":
+		price, acquiredAt := op.Price, op.Time
+		if b, ok := bases[op.ID]; ok {
+			price = b.Price
+			if b.AcquiredAt != nil {
+				acquiredAt = *b.AcquiredAt
+			}
+		} else if price == 0 {
+			l.missingBasis[op.Figi] += op.Quantity
+			l.missingBasisOps = append(l.missingBasisOps, op.ID)
+			return
+		}
+		l.totalBuys += price * op.Quantity
+		l.holdings[op.Figi] += op.Quantity
+		l.addLot(op.Figi, op.Quantity, price, acquiredAt)
+	case "OPERATION_TYPE_OUTPUT_SECURITIES":
+		qty := op.Quantity - l.takeMissingBasis(op.Figi, op.Quantity)
+		value := l.takeLots(op.Figi, qty)
+		if b, ok := bases[op.ID]; ok {
+			value = b.Price * qty
+		}
+		l.totalSells += value
+		l.holdings[op.Figi] -= qty
+	case "OPERATION_TYPE_BROKER_FEE", "OPERATION_TYPE_TRACK_MFEE", "OPERATION_TYPE_TRACK_PFEE":
+		l.commissions += -op.FloatPayment
+	case "OPERATION_TYPE_TAX":
+		l.taxes += -op.FloatPayment
+	}
+}
+
+func (l *ledger) applyCorporateAction(a models.CorporateAction) {
+	switch a.Type {
+	case models.CorporateActionSplit:
+		if qty, ok := l.holdings[a.FIGI]; ok {
+			l.holdings[a.FIGI] = qty * a.Ratio
+		}
+		if qty, ok := l.missingBasis[a.FIGI]; ok {
+			l.missingBasis[a.FIGI] = qty * a.Ratio
+		}
+		for i := range l.lots[a.FIGI] {
+			l.lots[a.FIGI][i].quantity *= a.Ratio
+			l.lots[a.FIGI][i].price /= a.Ratio
+		}
+	case models.CorporateActionConversion:
+		if qty, ok := l.holdings[a.FIGI]; ok {
+			l.holdings[a.NewFIGI] += qty * a.Ratio
+			delete(l.holdings, a.FIGI)
+		}
+		if qty, ok := l.missingBasis[a.FIGI]; ok {
+			l.missingBasis[a.NewFIGI] += qty * a.Ratio
+			delete(l.missingBasis, a.FIGI)
+		}
+		for _, lt := range l.lots[a.FIGI] {
+			l.addLot(a.NewFIGI, lt.quantity*a.Ratio, lt.price/a.Ratio, lt.acquiredAt)
+		}
+		delete(l.lots, a.FIGI)
+	case models.CorporateActionDelisting:
+		l.delisted[a.FIGI] = true
+	}
+}
+
+// addLot добавляет партию, сохраняя порядок по дате приобретения: введённые бумаги
+// могли быть куплены раньше уже открытых лотов.
+func (l *ledger) addLot(figi string, quantity, price float64, acquiredAt time.Time) {
+	lots := append(l.lots[figi], lot{quantity: quantity, price: price, acquiredAt: acquiredAt})
+	sort.SliceStable(lots, func(i, j int) bool {
+		return lots[i].acquiredAt.Before(lots[j].acquiredAt)
+	})
+	l.lots[figi] = lots
+}
+
+// takeMissingBasis списывает из бумаг без стоимости приобретения ту часть quantity, которой
+// не хватает в holdings, и возвращает списанное количество.
+func (l *ledger) takeMissingBasis(figi string, quantity float64) float64 {
+	excess := quantity - math.Max(l.holdings[figi], 0)
+	if excess < 0.0001 || l.missingBasis[figi] < 0.0001 {
+		return 0
+	}
+	taken := math.Min(excess, l.missingBasis[figi])
+	l.missingBasis[figi] -= taken
+	return taken
+}
+
+// takeLots списывает quantity бумаг по FIFO и возвращает их стоимость приобретения.
+func (l *ledger) takeLots(figi string, quantity float64) float64 {
+	var cost float64
+	lots := l.lots[figi]
+	for quantity > 0.0001 && len(lots) > 0 {
+		taken := math.Min(quantity, lots[0].quantity)
+		cost += taken * lots[0].price
+		lots[0].quantity -= taken
+		quantity -= taken
+		if lots[0].quantity < 0.0001 {
+			lots = lots[1:]
+		}
+	}
+	l.lots[figi] = lots
+	return cost
+}
+
+// valuePositions оценивает открытые позиции и возвращает разбивку по инструментам и цены,
+// полученные из API. Если цену из API получить нельзя (делистинг, заморозка), используется
+// ручная или последняя известная цена.
+func (a *App) valuePositions(ctx context.Context, l *ledger) ([]models.InstrumentSummary, []models.InstrumentPrice, error) {
+	known, err := a.Repo.GetInstrumentPrices(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var instruments []models.InstrumentSummary
+	var live []models.InstrumentPrice
+	for figi, qty := range l.holdings {
+		if figi == "" || math.Abs(qty) < 0.0001 {
+			continue
+		}
+		name, price, fromAPI, ok := a.positionPrice(ctx, figi, l.delisted[figi], known[figi])
+		if fromAPI {
+			live = append(live, models.InstrumentPrice{FIGI: figi, Name: name, LastPrice: &price})
+		}
+		if !ok {
+			slog.WarnContext(ctx, "Нет цены, позиция не учтена в стоимости портфеля", "figi", figi, "quantity", qty)
+			continue
+		}
+		instruments = append(instruments, models.InstrumentSummary{
+			FIGI:     figi,
+			Name:     name,
+			Quantity: qty,
+			Price:    price,
+			Value:    round2(qty * price),
+		})
+	}
+	sort.Slice(instruments, func(i, j int) bool {
+		return instruments[i].Value > instruments[j].Value
+	})
+	return instruments, live, nil
+}
+
+// positionPrice возвращает название и цену инструмента; fromAPI — цена получена из API сейчас.
+func (a *App) positionPrice(ctx context.Context, figi string, delisted bool, known models.InstrumentPrice) (name string, price float64, fromAPI, ok bool) {
+	if !delisted {
+		priceData, err := a.Tinkoff.GetFigiPrice(ctx, figi)
+		if err == nil {
+			return priceData.Name, priceData.Price, true, true
+		}
+		slog.WarnContext(ctx, "Не удалось получить цену", "figi", figi, "error", err)
+	}
+
+	if known.ManualPrice != nil {
+		return known.Name, *known.ManualPrice, false, true
+	}
+	if known.LastPrice != nil {
+		return known.Name, *known.LastPrice, false, true
+	}
+	return "", 0, false, false
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
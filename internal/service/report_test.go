@@ -0,0 +1,250 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"tinvest_report/internal/models"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC)
+}
+
+func op(d int, typ, figi string, qty, payment float64) Operation {
+	return Operation{
+		ID:           fmt.Sprintf("%s-%s-%d", typ, figi, d),
+		Operation:    typ,
+		Figi:         figi,
+		Quantity:     qty,
+		FloatPayment: payment,
+		Time:         day(d),
+	}
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func replayAll(ops []Operation, actions []models.CorporateAction, bases map[string]models.SecurityTransferBasis) *ledger {
+	rp := newReplay(ops, actions, bases)
+	rp.until(day(31))
+	return rp.ledger
+}
+
+func TestLedgerTotals(t *testing.T) {
+	canceled := op(5, "OPERATION_TYPE_BUY", "AAA", 100, -10000)
+	canceled.IsCanceled = true
+
+	l := replayAll([]Operation{
+		// Порядок намеренно нарушен: replay сортирует операции по времени.
+		op(3, "OPERATION_TYPE_SELL", "AAA", 4, 600),
+		op(1, "OPERATION_TYPE_INPUT", "", 0, 10000),
+		op(2, "OPERATION_TYPE_BUY", "AAA", 10, -1000),
+		op(2, "OPERATION_TYPE_BROKER_FEE", "AAA", 0, -3),
+		op(3, "OPERATION_TYPE_TAX", "", 0, -13),
+		op(4, "OPERATION_TYPE_BUY", "FUTSI0324000", 1, -500),
+		op(6, "OPERATION_TYPE_OUTPUT", "", 0, -2000),
+		canceled,
+	}, nil, nil)
+
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"totalInput", l.totalInput, 10000},
+		{"totalOutput", l.totalOutput, 2000},
+		{"totalBuys", l.totalBuys, 1000},
+		{"totalSells", l.totalSells, 600},
+		{"turnover", l.turnover, 1600},
+		{"commissions", l.commissions, 3},
+		{"taxes", l.taxes, 13},
+		// Фьючерсы не попадают в покупки, но их платежи меняют остаток денег.
+		{"cash", l.cash, 10000 - 1000 - 3 + 600 - 13 - 500 - 2000},
+		{"holdings AAA", l.holdings["AAA"], 6},
+	}
+	for _, c := range checks {
+		if !approx(c.got, c.want) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if _, ok := l.holdings["FUTSI0324000"]; ok {
+		t.Error("futures must not be tracked in holdings")
+	}
+	if !l.firstOperation.Equal(day(1)) {
+		t.Errorf("firstOperation = %v, want %v", l.firstOperation, day(1))
+	}
+}
+
+func TestReplayUntil(t *testing.T) {
+	rp := newReplay([]Operation{
+		op(1, "OPERATION_TYPE_BUY", "AAA", 10, -1000),
+		op(3, "OPERATION_TYPE_BUY", "AAA", 5, -600),
+	}, nil, nil)
+
+	rp.until(day(2))
+	if got := rp.ledger.holdings["AAA"]; !approx(got, 10) {
+		t.Fatalf("holdings after day 2 = %v, want 10", got)
+	}
+	rp.until(day(3))
+	if got := rp.ledger.holdings["AAA"]; !approx(got, 15) {
+		t.Fatalf("holdings after day 3 = %v, want 15", got)
+	}
+}
+
+func TestTakeLotsFIFO(t *testing.T) {
+	l := replayAll([]Operation{
+		op(1, "OPERATION_TYPE_BUY", "AAA", 10, -1000), // 100 за штуку
+		op(2, "OPERATION_TYPE_BUY", "AAA", 10, -1200), // 120 за штуку
+	}, nil, nil)
+
+	if cost := l.takeLots("AAA", 15); !approx(cost, 10*100+5*120) {
+		t.Errorf("cost of 15 = %v, want %v", cost, 10*100+5*120)
+	}
+	lots := l.lots["AAA"]
+	if len(lots) != 1 || !approx(lots[0].quantity, 5) || !approx(lots[0].price, 120) {
+		t.Fatalf("remaining lots = %+v, want one lot of 5 at 120", lots)
+	}
+	// Списание сверх остатка забирает только то, что есть.
+	if cost := l.takeLots("AAA", 100); !approx(cost, 5*120) {
+		t.Errorf("cost of oversell = %v, want %v", cost, 5*120)
+	}
+	if len(l.lots["AAA"]) != 0 {
+		t.Errorf("lots after oversell = %+v, want none", l.lots["AAA"])
+	}
+}
+
+func TestAddLotKeepsAcquisitionOrder(t *testing.T) {
+	acquired := day(1).AddDate(-2, 0, 0)
+	l := replayAll([]Operation{
+		op(1, "OPERATION_TYPE_BUY", "AAA", 10, -1000),
+		{ID: "in-1", Operation: "OPERATION_TYPE_INPUT_SECURITIES", Figi: "AAA", Quantity: 5, Time: day(2)},
+	}, nil, map[string]models.SecurityTransferBasis{
+		"in-1": {OperationID: "in-1", Price: 50, AcquiredAt: &acquired},
+	})
+
+	lots := l.lots["AAA"]
+	if len(lots) != 2 {
+		t.Fatalf("lots = %+v, want 2", lots)
+	}
+	if !lots[0].acquiredAt.Equal(acquired) || !approx(lots[0].price, 50) {
+		t.Errorf("first lot = %+v, want transferred lot bought %v at 50", lots[0], acquired)
+	}
+	// FIFO списывает сначала лот, купленный у прежнего брокера.
+	if cost := l.takeLots("AAA", 5); !approx(cost, 250) {
+		t.Errorf("cost = %v, want 250", cost)
+	}
+}
+
+func TestCorporateActions(t *testing.T) {
+	l := replayAll([]Operation{
+		op(1, "OPERATION_TYPE_BUY", "AAA", 10, -1000),
+		op(1, "OPERATION_TYPE_BUY", "OLD", 4, -400),
+		op(5, "OPERATION_TYPE_SELL", "AAA", 50, 600),
+	}, []models.CorporateAction{
+		{Type: models.CorporateActionSplit, FIGI: "AAA", Ratio: 10, EffectiveDate: day(2)},
+		{Type: models.CorporateActionConversion, FIGI: "OLD", NewFIGI: "NEW", Ratio: 0.5, EffectiveDate: day(3)},
+		{Type: models.CorporateActionDelisting, FIGI: "NEW", EffectiveDate: day(4)},
+	}, nil)
+
+	if got := l.holdings["AAA"]; !approx(got, 50) {
+		t.Errorf("AAA holdings after split and sell = %v, want 50", got)
+	}
+	if lots := l.lots["AAA"]; len(lots) != 1 || !approx(lots[0].quantity, 50) || !approx(lots[0].price, 10) {
+		t.Errorf("AAA lots = %+v, want 50 at 10", lots)
+	}
+	if _, ok := l.holdings["OLD"]; ok {
+		t.Error("converted FIGI must leave holdings")
+	}
+	if got := l.holdings["NEW"]; !approx(got, 2) {
+		t.Errorf("NEW holdings = %v, want 2", got)
+	}
+	if lots := l.lots["NEW"]; len(lots) != 1 || !approx(lots[0].price, 200) {
+		t.Errorf("NEW lots = %+v, want one lot at 200", lots)
+	}
+	if !l.delisted["NEW"] {
+		t.Error("NEW must be delisted")
+	}
+}
+
+func TestManualToOperation(t *testing.T) {
+	m := models.ManualOperation{
+		ID:            7,
+		Currency:      "rub",
+		FloatPayment:  -29050,
+		Date:          day(15),
+		OperationType: "OPERATION_TYPE_BUY",
+		FIGI:          "AAA",
+		Quantity:      100,
+		Price:         290.5,
+	}
+	got := manualToOperation(m)
+	if got.ID != "manual-7" || !got.IsManual || !got.Time.Equal(m.Date) || got.Date != "15/01/2024" {
+		t.Errorf("manualToOperation = %+v", got)
+	}
+
+	l := replayAll([]Operation{got}, nil, nil)
+	if !approx(l.totalBuys, 29050) || !approx(l.holdings["AAA"], 100) {
+		t.Errorf("manual buy: totalBuys = %v, holdings = %v", l.totalBuys, l.holdings["AAA"])
+	}
+}
+
+func TestMissingCostBasisExcludedFromProfit(t *testing.T) {
+	in := Operation{ID: "in-1", Operation: "OPERATION_TYPE_INPUT_SECURITIES", Figi: "AAA", Quantity: 10, Time: day(1)}
+	ops := []Operation{
+		in,
+		op(2, "OPERATION_TYPE_BUY", "AAA", 5, -500),
+		// Продаётся 8 бумаг: 5 купленных и 3 введённых без стоимости.
+		op(4, "OPERATION_TYPE_SELL", "AAA", 8, 960),
+	}
+	split := []models.CorporateAction{{Type: models.CorporateActionSplit, FIGI: "AAA", Ratio: 2, EffectiveDate: day(3)}}
+
+	l := replayAll(ops, split, nil)
+	if len(l.missingBasisOps) != 1 || l.missingBasisOps[0] != "in-1" {
+		t.Fatalf("missingBasisOps = %v, want [in-1]", l.missingBasisOps)
+	}
+	// После сплита 2:1 — 10 купленных бумаг и 20 введённых; продажа 8 бумаг полностью
+	// покрывается купленными.
+	if !approx(l.totalSells, 960) || !approx(l.holdings["AAA"], 2) || !approx(l.missingBasis["AAA"], 20) {
+		t.Errorf("after split: totalSells = %v, holdings = %v, missingBasis = %v", l.totalSells, l.holdings["AAA"], l.missingBasis["AAA"])
+	}
+	if !approx(l.totalBuys, 500) {
+		t.Errorf("totalBuys = %v, want 500", l.totalBuys)
+	}
+
+	l = replayAll(ops, nil, nil)
+	// Без сплита 3 из 8 проданных бумаг — без стоимости, их выручка не входит в продажи.
+	if !approx(l.totalSells, 960*5/8) || !approx(l.holdings["AAA"], 0) || !approx(l.missingBasis["AAA"], 7) {
+		t.Errorf("totalSells = %v, holdings = %v, missingBasis = %v", l.totalSells, l.holdings["AAA"], l.missingBasis["AAA"])
+	}
+	if !approx(l.turnover, 500+960) {
+		t.Errorf("turnover = %v, want full amount %v", l.turnover, 500+960)
+	}
+
+	// Указанная стоимость возвращает бумаги в лоты и отчёт становится полным.
+	l = replayAll(ops, nil, map[string]models.SecurityTransferBasis{"in-1": {OperationID: "in-1", Price: 90}})
+	if len(l.missingBasisOps) != 0 || !approx(l.totalBuys, 500+900) || !approx(l.totalSells, 960) || !approx(l.holdings["AAA"], 7) {
+		t.Errorf("with basis: missing = %v, totalBuys = %v, totalSells = %v, holdings = %v",
+			l.missingBasisOps, l.totalBuys, l.totalSells, l.holdings["AAA"])
+	}
+}
+
+func TestTransferDirection(t *testing.T) {
+	canceled := op(1, "OPERATION_TYPE_INPUT_SECURITIES", "AAA", 10, 0)
+	canceled.IsCanceled = true
+	for _, tt := range []struct {
+		op   Operation
+		want string
+	}{
+		{op(1, "OPERATION_TYPE_INPUT_SECURITIES", "AAA", 10, 0), models.TransferIn},
+		{op(1, "OPERATION_TYPE_OUTPUT_SECURITIES", "AAA", 10, 0), models.TransferOut},
+		{canceled, ""},
+		{op(1, "OPERATION_TYPE_BUY", "AAA", 10, -1000), ""},
+	} {
+		if got := transferDirection(tt.op); got != tt.want {
+			t.Errorf("transferDirection(%s, canceled=%v) = %q, want %q", tt.op.Operation, tt.op.IsCanceled, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"tinvest_report/internal/models"
+)
+
+// ApplyRetention прореживает сохранённые снимки по политике хранения. trigger — manual
+// для запуска через API, auto для задачи по расписанию. Задача по расписанию удаляет снимки,
+// только если эту же политику уже запускали через POST /summaries/retention: до этого
+// администратор не видел, что будет удалено, и прогон выполняется как dry run.
+func (a *App) ApplyRetention(ctx context.Context, policy models.RetentionPolicy, trigger string) (models.RetentionRun, error) {
+	if trigger == models.SummaryTriggerAuto && !policy.DryRun {
+		reviewed, err := a.Repo.RetentionPolicyReviewed(ctx, policy)
+		if err != nil {
+			return models.RetentionRun{}, err
+		}
+		if !reviewed {
+			slog.WarnContext(ctx, "Политика хранения ещё не проверена через POST /summaries/retention, снимки не удаляются",
+				"hourly_days", policy.HourlyDays, "daily_months", policy.DailyMonths)
+			policy.DryRun = true
+		}
+	}
+
+	now := time.Now()
+	return a.Repo.PruneSummaries(ctx, models.RetentionRun{
+		Trigger:      trigger,
+		HourlyDays:   policy.HourlyDays,
+		DailyMonths:  policy.DailyMonths,
+		DryRun:       policy.DryRun,
+		HourlyCutoff: now.AddDate(0, 0, -policy.HourlyDays),
+		DailyCutoff:  now.AddDate(0, -policy.DailyMonths, 0),
+	})
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/risk"
+)
+
+var ErrNoEquity = errors.New("нет данных о капитале за период, выполните POST /equity/rebuild")
+
+// RiskMetrics считает риск-метрики по сохранённому ряду капитала за период.
+// Бета считается, если задан benchmarkFIGI: доходности портфеля и бенчмарка
+// берутся между соседними торговыми днями бенчмарка.
+func (a *App) RiskMetrics(ctx context.Context, from, to time.Time, riskFreeRate float64, benchmarkFIGI string) (models.RiskMetrics, error) {
+	accountID := a.Tinkoff.AccountID()
+	points, err := a.Repo.GetEquity(ctx, accountID, from, to)
+	if err != nil {
+		return models.RiskMetrics{}, err
+	}
+	if len(points) < 2 {
+		return models.RiskMetrics{}, ErrNoEquity
+	}
+
+	index := make([]float64, len(points))
+	for i, p := range points {
+		index[i] = p.TWRIndex
+	}
+	returns := risk.Returns(index)
+	totalReturn := index[len(index)-1]/index[0] - 1
+	depth, duration := risk.MaxDrawdown(index)
+
+	m := models.RiskMetrics{
+		AccountID:        accountID,
+		From:             points[0].Date,
+		To:               points[len(points)-1].Date,
+		Days:             len(returns),
+		TotalReturn:      totalReturn,
+		AnnualizedReturn: risk.AnnualizedReturn(totalReturn, len(returns)),
+		Volatility:       risk.AnnualizedVolatility(returns),
+		MaxDrawdown:      depth,
+		MaxDrawdownDays:  duration,
+		Sharpe:           risk.Sharpe(returns, riskFreeRate),
+		Sortino:          risk.Sortino(returns, riskFreeRate),
+		RiskFreeRate:     riskFreeRate,
+		BenchmarkFIGI:    benchmarkFIGI,
+	}
+
+	if benchmarkFIGI != "" {
+		candles, err := a.Tinkoff.GetDailyCandles(ctx, benchmarkFIGI, m.From, m.To.AddDate(0, 0, 1))
+		if err != nil {
+			return models.RiskMetrics{}, err
+		}
+		byDate := make(map[time.Time]float64, len(points))
+		for _, p := range points {
+			byDate[truncateDay(p.Date)] = p.TWRIndex
+		}
+
+		var portfolio, benchmark []float64
+		for i := 1; i < len(candles); i++ {
+			prev, cur := truncateDay(candles[i-1].Time), truncateDay(candles[i].Time)
+			p0, ok0 := byDate[prev]
+			p1, ok1 := byDate[cur]
+			if !ok0 || !ok1 || p0 == 0 || candles[i-1].Close == 0 {
+				continue
+			}
+			portfolio = append(portfolio, p1/p0-1)
+			benchmark = append(benchmark, candles[i].Close/candles[i-1].Close-1)
+		}
+		beta := risk.Beta(portfolio, benchmark)
+		m.Beta = &beta
+	}
+	return m, nil
+}
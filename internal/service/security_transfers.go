@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"tinvest_report/internal/models"
+)
+
+// ldvHoldingPeriod — срок владения для льготы долгосрочного владения (ЛДВ).
+const ldvHoldingPeriod = 3
+
+var (
+	ErrOperationNotFound   = errors.New("операция не найдена")
+	ErrNotSecurityTransfer = errors.New("операция не является вводом или выводом бумаг")
+)
+
+// SaveSecurityTransferBasis сохраняет стоимость перевода бумаг. Стоимость можно указать только
+// для действующей операции ввода или вывода бумаг: для других операций её не учтёт воспроизведение.
+func (a *App) SaveSecurityTransferBasis(ctx context.Context, basis models.SecurityTransferBasis) error {
+	ops, err := a.GetOperations(ctx)
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		if op.ID != basis.OperationID {
+			continue
+		}
+		if transferDirection(op) == "" {
+			return ErrNotSecurityTransfer
+		}
+		return a.Repo.SaveSecurityTransferBasis(ctx, basis)
+	}
+	return ErrOperationNotFound
+}
+
+// transferDirection возвращает направление перевода бумаг или пустую строку, если op —
+// не действующий перевод.
+func transferDirection(op Operation) string {
+	if op.IsCanceled {
+		return ""
+	}
+	switch op.Operation {
+	case "OPERATION_TYPE_INPUT_SECURITIES":
+		return models.TransferIn
+	case "OPERATION_TYPE_OUTPUT_SECURITIES":
+		return models.TransferOut
+	}
+	return ""
+}
+
+// GetSecurityTransfers возвращает операции ввода и вывода бумаг вместе с указанной стоимостью.
+func (a *App) GetSecurityTransfers(ctx context.Context) ([]models.SecurityTransfer, error) {
+	ops, err := a.GetOperations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bases, err := a.Repo.GetSecurityTransferBases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var transfers []models.SecurityTransfer
+	for _, op := range ops {
+		direction := transferDirection(op)
+		if direction == "" {
+			continue
+		}
+
+		t := models.SecurityTransfer{
+			OperationID: op.ID,
+			Direction:   direction,
+			FIGI:        op.Figi,
+			Quantity:    op.Quantity,
+			Date:        op.Time,
+		}
+		if b, ok := bases[op.ID]; ok {
+			t.Basis = &b
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, nil
+}
+
+// GetOpenLots возвращает открытые лоты по FIFO с датами приобретения, включая
+// даты покупки у предыдущего брокера для введённых бумаг. Непустой tag оставляет лоты,
+// открытые операциями с этой меткой.
+func (a *App) GetOpenLots(ctx context.Context, tag string) ([]models.Lot, error) {
+	l, err := a.buildLedger(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var out []models.Lot
+	for figi, lots := range l.lots {
+		for _, lt := range lots {
+			out = append(out, models.Lot{
+				FIGI:        figi,
+				Quantity:    lt.quantity,
+				Price:       round2(lt.price),
+				AcquiredAt:  lt.acquiredAt,
+				LDVEligible: !lt.acquiredAt.AddDate(ldvHoldingPeriod, 0, 0).After(now),
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].FIGI != out[j].FIGI {
+			return out[i].FIGI < out[j].FIGI
+		}
+		return out[i].AcquiredAt.Before(out[j].AcquiredAt)
+	})
+	return out, nil
+}
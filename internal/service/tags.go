@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"tinvest_report/internal/models"
+)
+
+// operationTags возвращает метки операции, а если их нет — метки её инструмента.
+func operationTags(op Operation, assignments models.TagAssignments) []string {
+	if tags, ok := assignments.Operations[op.ID]; ok {
+		return tags
+	}
+	if op.Figi == "" {
+		return nil
+	}
+	return assignments.Instruments[op.Figi]
+}
+
+// filterByTag оставляет операции с меткой tag. Пустая метка — все операции,
+// models.UntaggedGroup — операции без меток. Пополнения и выводы относятся ко всему
+// счёту, а не к стратегии, поэтому в отбор по метке не попадают: иначе все они
+// оказались бы в untagged, а у остальных меток остаток денег ушёл бы в минус.
+func filterByTag(ops []Operation, tag string) []Operation {
+	if tag == "" {
+		return ops
+	}
+	var out []Operation
+	for _, op := range ops {
+		if !isCashTransfer(op) && hasTag(op, tag) {
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+func isCashTransfer(op Operation) bool {
+	switch op.Operation {
+	case "OPERATION_TYPE_INPUT", "OPERATION_TYPE_INP_MULTI",
+		"OPERATION_TYPE_OUTPUT", "OPERATION_TYPE_OUT_MULTI":
+		return true
+	}
+	return false
+}
+
+func hasTag(op Operation, tag string) bool {
+	if tag == models.UntaggedGroup {
+		return len(op.Tags) == 0
+	}
+	for _, t := range op.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// tags возвращает метки, встречающиеся в операциях, и models.UntaggedGroup,
+// если есть операции без меток.
+func (s *replaySource) tags() []string {
+	seen := make(map[string]bool)
+	untagged := false
+	for _, op := range s.ops {
+		if isCashTransfer(op) {
+			continue
+		}
+		if len(op.Tags) == 0 {
+			untagged = true
+		}
+		for _, t := range op.Tags {
+			seen[t] = true
+		}
+	}
+
+	tags := make([]string, 0, len(seen)+1)
+	for t := range seen {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	if untagged {
+		tags = append(tags, models.UntaggedGroup)
+	}
+	return tags
+}
+
+// BuildSummariesByTag рассчитывает отдельный отчёт по операциям каждой метки.
+// Операция с несколькими метками входит в отчёт каждой из них.
+func (a *App) BuildSummariesByTag(ctx context.Context) ([]Summary, error) {
+	src, err := a.loadReplaySource(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []Summary
+	for _, tag := range src.tags() {
+		summary, err := a.summarize(ctx, src.ledger(tag))
+		if err != nil {
+			return nil, err
+		}
+		summary.Tag = tag
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
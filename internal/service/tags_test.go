@@ -0,0 +1,44 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"tinvest_report/internal/models"
+)
+
+func TestTagLedgersExcludeCashTransfers(t *testing.T) {
+	tagged := func(o Operation, tags ...string) Operation {
+		o.Tags = tags
+		return o
+	}
+	src := &replaySource{ops: []Operation{
+		op(1, "OPERATION_TYPE_INPUT", "", 0, 10000),
+		tagged(op(2, "OPERATION_TYPE_BUY", "AAA", 10, -1000), "growth"),
+		op(3, "OPERATION_TYPE_BUY", "BBB", 5, -500),
+		op(4, "OPERATION_TYPE_OUTPUT", "", 0, -2000),
+	}}
+
+	if got := src.tags(); !reflect.DeepEqual(got, []string{"growth", models.UntaggedGroup}) {
+		t.Errorf("tags = %v", got)
+	}
+
+	untagged := src.ledger(models.UntaggedGroup)
+	if untagged.totalInput != 0 || untagged.totalOutput != 0 || !approx(untagged.cash, -500) {
+		t.Errorf("untagged: input = %v, output = %v, cash = %v; want no transfers", untagged.totalInput, untagged.totalOutput, untagged.cash)
+	}
+	if got := src.ledger("growth").holdings["AAA"]; !approx(got, 10) {
+		t.Errorf("growth holdings = %v, want 10", got)
+	}
+
+	all := src.ledger("")
+	if !approx(all.totalInput, 10000) || !approx(all.cash, 10000-1000-500-2000) {
+		t.Errorf("whole account: input = %v, cash = %v", all.totalInput, all.cash)
+	}
+
+	// Если на счёте только пополнения, меток нет вовсе.
+	src = &replaySource{ops: []Operation{op(1, "OPERATION_TYPE_INPUT", "", 0, 10000)}}
+	if got := src.tags(); len(got) != 0 {
+		t.Errorf("tags of deposits only = %v, want none", got)
+	}
+}
@@ -2,56 +2,129 @@ package service
 
 import (
 	"context"
-	"log"
-	"os"
+	"fmt"
+	"sync"
 	"time"
 
-	"github.com/joho/godotenv"
 	"github.com/vodolaz095/go-investAPI/investapi"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"tinvest_report/internal/config"
+	"tinvest_report/internal/logging"
 )
 
 type TinkoffClient struct {
 	conn        *grpc.ClientConn
-	ctx         context.Context
+	token       string
 	accountID   string
 	operations  investapi.OperationsServiceClient
 	instruments investapi.InstrumentsServiceClient
 	prices      investapi.MarketDataServiceClient
+	users       investapi.UsersServiceClient
+
+	infoMu    sync.Mutex
+	infoCache map[string]InstrumentInfo
+
+	syncMu   sync.Mutex
+	lastSync time.Time
 }
 
-func NewTinkoffClient() *TinkoffClient {
-	_ = godotenv.Load()
-	token := os.Getenv("TINKOFF_TOKEN")
-	if token == "" {
-		log.Fatal("TINKOFF_TOKEN не найден в .env")
+// NewTinkoffClient подключается к Tinkoff Invest API и выбирает счёт для отчётов.
+func NewTinkoffClient(cfg config.Tinkoff) (*TinkoffClient, error) {
+	conn, err := grpc.Dial(cfg.Endpoint,
+		grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(nil, "")),
+		grpc.WithUnaryInterceptor(tinkoffMetrics),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+	if err != nil {
+		return nil, fmt.Errorf("подключение к %s: %w", cfg.Endpoint, err)
+	}
+
+	c := &TinkoffClient{
+		conn:        conn,
+		token:       cfg.Token,
+		operations:  investapi.NewOperationsServiceClient(conn),
+		instruments: investapi.NewInstrumentsServiceClient(conn),
+		prices:      investapi.NewMarketDataServiceClient(conn),
+		users:       investapi.NewUsersServiceClient(conn),
+		infoCache:   make(map[string]InstrumentInfo),
 	}
 
-	conn, err := grpc.Dial("invest-public-api.tinkoff.ru:443",
-		grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(nil, "")))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	c.accountID, err = c.selectAccount(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Ошибка подключения: %v", err)
+		conn.Close()
+		return nil, err
 	}
+	logging.AddAccountID(c.accountID)
+	return c, nil
+}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "Authorization", "Bearer "+token)
+// selectAccount возвращает cfg.AccountID, если такой счёт есть, иначе счёт с номером cfg.AccountIndex.
+func (c *TinkoffClient) selectAccount(ctx context.Context, cfg config.Tinkoff) (string, error) {
+	resp, err := c.users.GetAccounts(c.auth(ctx), &investapi.GetAccountsRequest{})
+	if err != nil {
+		return "", fmt.Errorf("получение счетов: %w", err)
+	}
+	accounts := resp.Accounts
+	if cfg.AccountID != "" {
+		for _, a := range accounts {
+			if a.Id == cfg.AccountID {
+				return a.Id, nil
+			}
+		}
+		return "", fmt.Errorf("счёт %s не найден среди %d счетов токена", logging.MaskAccountID(cfg.AccountID), len(accounts))
+	}
+	if cfg.AccountIndex >= len(accounts) {
+		return "", fmt.Errorf("у токена %d счетов, счёта с номером %d (TINKOFF_ACCOUNT_INDEX, с нуля) нет", len(accounts), cfg.AccountIndex)
+	}
+	return accounts[cfg.AccountIndex].Id, nil
+}
 
-	usersClient := investapi.NewUsersServiceClient(conn)
-	accountsResp, err := usersClient.GetAccounts(ctx, &investapi.GetAccountsRequest{})
-	if err != nil || len(accountsResp.Accounts) == 0 {
-		log.Fatalf("Ошибка получения аккаунтов: %v", err)
+// auth добавляет к контексту запроса токен и ID HTTP-запроса, если вызов сделан при его обработке.
+// Контекст вызывающего передаётся дальше, чтобы отмена запроса и трассировка доходили до вызовов API.
+func (c *TinkoffClient) auth(ctx context.Context) context.Context {
+	ctx = metadata.AppendToOutgoingContext(ctx, "Authorization", "Bearer "+c.token)
+	if id := logging.RequestID(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", id)
 	}
+	return ctx
+}
 
-	return &TinkoffClient{
-		conn:        conn,
-		ctx:         ctx,
-		accountID:   accountsResp.Accounts[1].Id,
-		operations:  investapi.NewOperationsServiceClient(conn),
-		instruments: investapi.NewInstrumentsServiceClient(conn),
-		prices:      investapi.NewMarketDataServiceClient(conn),
+func (c *TinkoffClient) AccountID() string {
+	return c.accountID
+}
+
+// Close закрывает gRPC-соединение с Tinkoff Invest API.
+func (c *TinkoffClient) Close() error {
+	return c.conn.Close()
+}
+
+// ConnState возвращает состояние gRPC-соединения; простаивающее соединение при этом
+// начинает подключаться заново.
+func (c *TinkoffClient) ConnState() connectivity.State {
+	state := c.conn.GetState()
+	if state == connectivity.Idle {
+		c.conn.Connect()
 	}
+	return state
+}
+
+// CheckToken проверяет, что токен принимается API, запросом информации о пользователе.
+func (c *TinkoffClient) CheckToken(ctx context.Context) error {
+	_, err := c.users.GetInfo(c.auth(ctx), &investapi.GetInfoRequest{})
+	return err
+}
+
+// LastSync возвращает время последней успешной загрузки операций; нулевое, если её ещё не было.
+func (c *TinkoffClient) LastSync() time.Time {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+	return c.lastSync
 }
 
 type Operation struct {
@@ -65,18 +138,26 @@ type Operation struct {
 	Quantity     float64 `json:"quantity"`
 	Price        float64 `json:"price"`
 	IsCanceled   bool    `json:"is_canceled"`
+	IsManual     bool    `json:"is_manual"`
+	// Tags — метки операции, а если их нет — метки инструмента.
+	Tags []string `json:"tags,omitempty"`
+
+	Time time.Time `json:"-"`
 }
 
-func (c *TinkoffClient) GetOperations() ([]Operation, error) {
+func (c *TinkoffClient) GetOperations(ctx context.Context) ([]Operation, error) {
 	req := &investapi.OperationsRequest{
 		AccountId: c.accountID,
 		From:      timestamppb.New(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)),
 		To:        timestamppb.New(time.Now()),
 	}
-	resp, err := c.operations.GetOperations(c.ctx, req)
+	resp, err := c.operations.GetOperations(c.auth(ctx), req)
 	if err != nil {
 		return nil, err
 	}
+	c.syncMu.Lock()
+	c.lastSync = time.Now()
+	c.syncMu.Unlock()
 
 	var out []Operation
 	for _, op := range resp.Operations {
@@ -95,6 +176,7 @@ func (c *TinkoffClient) GetOperations() ([]Operation, error) {
 			Quantity:     quantity,
 			Price:        price,
 			IsCanceled:   op.State == investapi.OperationState_OPERATION_STATE_CANCELED,
+			Time:         op.Date.AsTime(),
 		})
 	}
 	return out, nil
@@ -105,8 +187,8 @@ type StockData struct {
 	Price float64 `json:"price"`
 }
 
-func (c *TinkoffClient) GetFigiPrice(figi string) (StockData, error) {
-	instrResp, err := c.instruments.GetInstrumentBy(c.ctx, &investapi.InstrumentRequest{
+func (c *TinkoffClient) GetFigiPrice(ctx context.Context, figi string) (StockData, error) {
+	instrResp, err := c.instruments.GetInstrumentBy(c.auth(ctx), &investapi.InstrumentRequest{
 		IdType: investapi.InstrumentIdType_INSTRUMENT_ID_TYPE_FIGI,
 		Id:     figi,
 	})
@@ -114,12 +196,15 @@ func (c *TinkoffClient) GetFigiPrice(figi string) (StockData, error) {
 		return StockData{}, err
 	}
 
-	priceResp, err := c.prices.GetLastPrices(c.ctx, &investapi.GetLastPricesRequest{
+	priceResp, err := c.prices.GetLastPrices(c.auth(ctx), &investapi.GetLastPricesRequest{
 		Figi: []string{figi},
 	})
-	if err != nil || len(priceResp.LastPrices) == 0 {
+	if err != nil {
 		return StockData{}, err
 	}
+	if len(priceResp.LastPrices) == 0 || priceResp.LastPrices[0].Price == nil {
+		return StockData{}, fmt.Errorf("нет последней цены для %s", figi)
+	}
 
 	p := priceResp.LastPrices[0].Price
 	price := float64(p.Units) + float64(p.Nano)/1e9
@@ -129,3 +214,116 @@ func (c *TinkoffClient) GetFigiPrice(figi string) (StockData, error) {
 		Price: price,
 	}, nil
 }
+
+// GetPositions возвращает фактическое количество бумаг на счёте по FIGI (без валют).
+func (c *TinkoffClient) GetPositions(ctx context.Context) (map[string]float64, error) {
+	resp, err := c.operations.GetPortfolio(c.auth(ctx), &investapi.PortfolioRequest{AccountId: c.accountID})
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]float64)
+	for _, p := range resp.Positions {
+		if p.InstrumentType == "currency" {
+			continue
+		}
+		positions[p.Figi] = float64(p.Quantity.GetUnits()) + float64(p.Quantity.GetNano())/1e9
+	}
+	return positions, nil
+}
+
+type Candle struct {
+	Time  time.Time
+	Close float64
+}
+
+// GetDailyCandles возвращает дневные свечи за период. API отдаёт дневные свечи
+// не больше чем за год на запрос, поэтому период запрашивается по частям.
+func (c *TinkoffClient) GetDailyCandles(ctx context.Context, figi string, from, to time.Time) ([]Candle, error) {
+	var out []Candle
+	for start := from; start.Before(to); start = start.AddDate(1, 0, 0) {
+		end := start.AddDate(1, 0, 0)
+		if end.After(to) {
+			end = to
+		}
+
+		resp, err := c.prices.GetCandles(c.auth(ctx), &investapi.GetCandlesRequest{
+			Figi:     figi,
+			From:     timestamppb.New(start),
+			To:       timestamppb.New(end),
+			Interval: investapi.CandleInterval_CANDLE_INTERVAL_DAY,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, candle := range resp.Candles {
+			out = append(out, Candle{
+				Time:  candle.Time.AsTime(),
+				Close: float64(candle.Close.GetUnits()) + float64(candle.Close.GetNano())/1e9,
+			})
+		}
+	}
+	return out, nil
+}
+
+type InstrumentInfo struct {
+	FIGI     string `json:"figi"`
+	Ticker   string `json:"ticker"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Currency string `json:"currency"`
+	Country  string `json:"country"`
+	Sector   string `json:"sector"`
+	Lot      int32  `json:"lot"`
+}
+
+// GetInstrumentInfo возвращает справочные данные инструмента. Сектор есть только
+// у акций, облигаций и фондов, поэтому для них делается дополнительный запрос.
+// Справочные данные не меняются, поэтому кэшируются на время работы процесса.
+func (c *TinkoffClient) GetInstrumentInfo(ctx context.Context, figi string) (InstrumentInfo, error) {
+	c.infoMu.Lock()
+	info, ok := c.infoCache[figi]
+	c.infoMu.Unlock()
+	if ok {
+		return info, nil
+	}
+
+	req := &investapi.InstrumentRequest{
+		IdType: investapi.InstrumentIdType_INSTRUMENT_ID_TYPE_FIGI,
+		Id:     figi,
+	}
+	resp, err := c.instruments.GetInstrumentBy(c.auth(ctx), req)
+	if err != nil {
+		return InstrumentInfo{}, err
+	}
+	instr := resp.Instrument
+	info = InstrumentInfo{
+		FIGI:     figi,
+		Ticker:   instr.Ticker,
+		Name:     instr.Name,
+		Type:     instr.InstrumentType,
+		Currency: instr.Currency,
+		Country:  instr.CountryOfRisk,
+		Lot:      instr.Lot,
+	}
+
+	switch instr.InstrumentType {
+	case "share":
+		if r, err := c.instruments.ShareBy(c.auth(ctx), req); err == nil {
+			info.Sector = r.Instrument.Sector
+		}
+	case "bond":
+		if r, err := c.instruments.BondBy(c.auth(ctx), req); err == nil {
+			info.Sector = r.Instrument.Sector
+		}
+	case "etf":
+		if r, err := c.instruments.EtfBy(c.auth(ctx), req); err == nil {
+			info.Sector = r.Instrument.Sector
+		}
+	}
+
+	c.infoMu.Lock()
+	c.infoCache[figi] = info
+	c.infoMu.Unlock()
+	return info, nil
+}
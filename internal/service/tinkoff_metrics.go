@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"tinvest_report/internal/metrics"
+)
+
+// tinkoffMetrics считает вызовы API, запоминает остаток лимита запросов из заголовков ответа
+// и пишет вызов в лог вместе с x-tracking-id, по которому поддержка Tinkoff находит запрос.
+func tinkoffMetrics(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	var header metadata.MD
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+
+	// /tinkoff.public.invest.api.contract.v1.OperationsService/GetOperations → OperationsService/GetOperations
+	name := method[strings.LastIndex(method, ".")+1:]
+	elapsed := time.Since(start)
+	metrics.TinkoffRequests.WithLabelValues(name, status.Code(err).String()).Inc()
+	metrics.TinkoffDuration.WithLabelValues(name).Observe(elapsed.Seconds())
+
+	attrs := []any{"method", name, "duration", elapsed}
+	if v := header.Get("x-tracking-id"); len(v) > 0 {
+		attrs = append(attrs, "tracking_id", v[0])
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Ошибка вызова Tinkoff API", append(attrs, "code", status.Code(err).String(), "error", err)...)
+	} else {
+		slog.DebugContext(ctx, "Вызов Tinkoff API", attrs...)
+	}
+
+	if v, ok := rateLimitHeader(header, "x-ratelimit-limit"); ok {
+		metrics.TinkoffRateLimit.WithLabelValues(name).Set(v)
+	}
+	if v, ok := rateLimitHeader(header, "x-ratelimit-remaining"); ok {
+		metrics.TinkoffRateLimitRemaining.WithLabelValues(name).Set(v)
+	}
+	return err
+}
+
+// rateLimitHeader читает первое число заголовка: x-ratelimit-limit приходит в виде "200, 200;w=60".
+func rateLimitHeader(md metadata.MD, key string) (float64, bool) {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return 0, false
+	}
+	v, _, _ := strings.Cut(values[0], ",")
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return 0, false
+	}
+	return float64(n), true
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/repository"
+)
+
+var (
+	ErrUnauthorized = errors.New("неизвестный ключ API")
+	ErrInvalidRole  = errors.New("роль должна быть viewer или admin")
+	ErrLastAdmin    = repository.ErrLastAdmin
+	ErrUserExists   = errors.New("пользователь с таким именем уже существует")
+)
+
+// apiKeyPrefix отличает ключи API от других токенов, например при поиске утечек в коде.
+const apiKeyPrefix = "tir_"
+
+// AdminTokenUser — пользователь, от имени которого выполняются запросы с ADMIN_TOKEN.
+const AdminTokenUser = "admin-token"
+
+// userTouchInterval — не чаще этого обновляется last_used_at, чтобы запросы
+// не писали в БД каждый раз.
+const userTouchInterval = time.Minute
+
+// Authenticate возвращает владельца ключа. ADMIN_TOKEN из настроек действует как ключ
+// администратора, чтобы создать первого пользователя.
+func (a *App) Authenticate(ctx context.Context, key string) (models.User, error) {
+	if admin := a.Config.AdminToken; admin != "" && subtle.ConstantTimeCompare([]byte(key), []byte(admin)) == 1 {
+		return models.User{Name: AdminTokenUser, Role: models.RoleAdmin}, nil
+	}
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return models.User{}, ErrUnauthorized
+	}
+	u, err := a.Repo.GetUserByKeyHash(ctx, hashAPIKey(key))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.User{}, ErrUnauthorized
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	if now := time.Now(); u.LastUsedAt == nil || now.Sub(*u.LastUsedAt) >= userTouchInterval {
+		if err := a.Repo.TouchUser(ctx, u.ID, now); err != nil {
+			slog.WarnContext(ctx, "Не удалось отметить использование ключа", "user", u.Name, "error", err)
+		}
+	}
+	return u, nil
+}
+
+// CreateUser создаёт пользователя и выпускает ему ключ.
+func (a *App) CreateUser(ctx context.Context, name, role string) (models.UserKey, error) {
+	if !validRole(role) {
+		return models.UserKey{}, ErrInvalidRole
+	}
+	key, prefix, hash, err := newAPIKey()
+	if err != nil {
+		return models.UserKey{}, err
+	}
+	u, err := a.Repo.CreateUser(ctx, name, role, prefix, hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.UserKey{}, ErrUserExists
+	}
+	if err != nil {
+		return models.UserKey{}, err
+	}
+	return models.UserKey{User: u, Key: key}, nil
+}
+
+// RotateUserKey выпускает пользователю новый ключ, старый перестаёт действовать.
+func (a *App) RotateUserKey(ctx context.Context, id int64) (models.UserKey, error) {
+	key, prefix, hash, err := newAPIKey()
+	if err != nil {
+		return models.UserKey{}, err
+	}
+	u, err := a.Repo.ReplaceUserKey(ctx, id, prefix, hash)
+	if err != nil {
+		return models.UserKey{}, err
+	}
+	return models.UserKey{User: u, Key: key}, nil
+}
+
+// SetUserRole меняет роль пользователя. Последнего администратора понизить нельзя.
+func (a *App) SetUserRole(ctx context.Context, id int64, role string) (models.User, error) {
+	if !validRole(role) {
+		return models.User{}, ErrInvalidRole
+	}
+	return a.Repo.UpdateUserRole(ctx, id, role)
+}
+
+// DeleteUser удаляет пользователя. Последнего администратора удалить нельзя.
+func (a *App) DeleteUser(ctx context.Context, id int64) error {
+	return a.Repo.DeleteUser(ctx, id)
+}
+
+func validRole(role string) bool {
+	return role == models.RoleViewer || role == models.RoleAdmin
+}
+
+// newAPIKey генерирует ключ из 32 случайных байт. Ключ случайный и длинный, поэтому
+// для хранения достаточно SHA-256: перебор по хэшу невозможен, а поиск по нему — один индекс.
+func newAPIKey() (key, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("генерация ключа: %w", err)
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:len(apiKeyPrefix)+6], hashAPIKey(key), nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
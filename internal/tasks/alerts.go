@@ -0,0 +1,23 @@
+package tasks
+
+import (
+	"context"
+	"log/slog"
+
+	"tinvest_report/internal/scheduler"
+	"tinvest_report/internal/service"
+)
+
+// evaluateAlerts проверяет правила оповещений.
+func evaluateAlerts(app *service.App) scheduler.JobFunc {
+	return func(ctx context.Context) error {
+		fired, err := app.EvaluateAlerts(ctx)
+		if err != nil {
+			return err
+		}
+		if fired > 0 {
+			slog.InfoContext(ctx, "Сработали оповещения", "count", fired)
+		}
+		return nil
+	}
+}
@@ -1,49 +1,21 @@
 package tasks
 
 import (
-	"bytes"
-	"encoding/json"
-	"log"
-	"net/http"
-	"time"
+	"context"
+	"log/slog"
+
 	"tinvest_report/internal/models"
+	"tinvest_report/internal/scheduler"
+	"tinvest_report/internal/service"
 )
 
-func AutoSaveSummary(interval time.Duration) {
-	go func() {
-		for {
-			log.Println("⏱ Автосохранение summary...")
-			saveSummaryOnce()
-			time.Sleep(interval)
+// saveSummary сохраняет снимок отчёта с trigger=auto.
+func saveSummary(app *service.App) scheduler.JobFunc {
+	return func(ctx context.Context) error {
+		if _, err := app.SaveSnapshot(ctx, models.SummaryTriggerAuto); err != nil {
+			return err
 		}
-	}()
-}
-
-func saveSummaryOnce() {
-	resp, err := http.Get("http://localhost:8080/summary")
-	if err != nil {
-		log.Println("⚠️ Ошибка запроса /summary:", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	var summary models.Summary
-	if err := json.NewDecoder(resp.Body).Decode(&summary); err != nil {
-		log.Println("⚠️ Ошибка декодирования summary:", err)
-		return
+		slog.InfoContext(ctx, "Summary сохранен автоматически")
+		return nil
 	}
-
-	body, err := json.Marshal(summary)
-	if err != nil {
-		log.Println("⚠️ Ошибка маршалинга summary:", err)
-		return
-	}
-
-	_, err = http.Post("http://localhost:8080/summary/save", "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		log.Println("⚠️ Ошибка POST /summary/save:", err)
-		return
-	}
-
-	log.Println("✅ Summary сохранен")
 }
@@ -0,0 +1,24 @@
+package tasks
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"tinvest_report/internal/scheduler"
+	"tinvest_report/internal/service"
+)
+
+// sendDigests отправляет письма с отчётами по расписанию подписок.
+func sendDigests(app *service.App) scheduler.JobFunc {
+	return func(ctx context.Context) error {
+		sent, err := app.SendDueDigests(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		if sent > 0 {
+			slog.InfoContext(ctx, "Отправлены дайджесты", "count", sent)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,21 @@
+package tasks
+
+import (
+	"context"
+	"log/slog"
+
+	"tinvest_report/internal/scheduler"
+	"tinvest_report/internal/service"
+)
+
+// rebuildEquity пересчитывает дневной ряд капитала.
+func rebuildEquity(app *service.App) scheduler.JobFunc {
+	return func(ctx context.Context) error {
+		points, err := app.RebuildEquity(ctx)
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "Капитал пересчитан", "days", len(points))
+		return nil
+	}
+}
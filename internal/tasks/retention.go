@@ -0,0 +1,26 @@
+package tasks
+
+import (
+	"context"
+	"log/slog"
+
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/scheduler"
+	"tinvest_report/internal/service"
+)
+
+// pruneSummaries прореживает снимки по политике хранения app.Retention.
+func pruneSummaries(app *service.App) scheduler.JobFunc {
+	return func(ctx context.Context) error {
+		run, err := app.ApplyRetention(ctx, app.Retention, models.SummaryTriggerAuto)
+		if err != nil {
+			return err
+		}
+		if run.DryRun {
+			slog.InfoContext(ctx, "Очистка снимков (dry run)", "to_prune", run.PrunedCount)
+			return nil
+		}
+		slog.InfoContext(ctx, "Очистка снимков", "pruned", run.PrunedCount)
+		return nil
+	}
+}
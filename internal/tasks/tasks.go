@@ -0,0 +1,31 @@
+package tasks
+
+import (
+	"time"
+
+	"tinvest_report/internal/config"
+	"tinvest_report/internal/scheduler"
+	"tinvest_report/internal/service"
+)
+
+// Register добавляет фоновые задачи приложения в планировщик по расписаниям из sch.
+func Register(s *scheduler.Scheduler, app *service.App, sch config.Jobs) error {
+	jobs := []struct {
+		name    string
+		spec    string
+		timeout time.Duration
+		fn      scheduler.JobFunc
+	}{
+		{"autosave", sch.Autosave, 5 * time.Minute, saveSummary(app)},
+		{"retention", sch.Retention, 5 * time.Minute, pruneSummaries(app)},
+		{"equity", sch.Equity, 10 * time.Minute, rebuildEquity(app)},
+		{"alerts", sch.Alerts, 5 * time.Minute, evaluateAlerts(app)},
+		{"digests", sch.Digests, 10 * time.Minute, sendDigests(app)},
+	}
+	for _, j := range jobs {
+		if err := s.Add(j.name, j.spec, j.timeout, j.fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
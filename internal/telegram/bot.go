@@ -0,0 +1,228 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"tinvest_report/internal/logging"
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/scheduler"
+	"tinvest_report/internal/service"
+)
+
+// DefaultAPIURL — адрес Bot API; для тестов его можно заменить адресом локальной заглушки.
+const DefaultAPIURL = "https://api.telegram.org"
+
+// Задачи планировщика, через которые работает бот.
+const (
+	// PollJob получает обновления. Запуск не завершается, пока не отменён контекст,
+	// поэтому при нескольких репликах опрашивает Telegram только та, что держит
+	// блокировку задачи; остальные раз в минуту пробуют её перехватить.
+	PollJob = "telegram-poll"
+	// DigestJob отправляет ежедневный дайджест.
+	DigestJob = "telegram-digest"
+)
+
+// pollSchedule — как часто реплика без блокировки PollJob пробует начать опрос.
+const pollSchedule = "* * * * *"
+
+// Config — настройки бота.
+type Config struct {
+	Token  string
+	APIURL string
+	// AllowedChats — чаты, которым бот отвечает и в которые отправляет дайджест.
+	// Остальные сообщения игнорируются, чтобы отчёт не увидел посторонний.
+	AllowedChats []int64
+	// DigestHour — час (по локальному времени сервера) отправки ежедневного дайджеста; -1 — не отправлять.
+	DigestHour  int
+	PollTimeout time.Duration
+}
+
+// Reports — расчёты, которые бот показывает в ответах; их выполняет *service.App.
+type Reports interface {
+	BuildSummary(ctx context.Context, tag string) (service.Summary, error)
+	GetAllocation(ctx context.Context, tag string) (models.Allocation, error)
+	InstrumentPnL(ctx context.Context, ticker string) (models.InstrumentPnL, error)
+}
+
+// Bot отвечает на команды через long polling и рассылает ежедневный дайджест.
+type Bot struct {
+	app    Reports
+	cfg    Config
+	client *http.Client
+	offset int64
+}
+
+func NewBot(app Reports, cfg Config) *Bot {
+	if cfg.APIURL == "" {
+		cfg.APIURL = DefaultAPIURL
+	}
+	if cfg.PollTimeout <= 0 {
+		cfg.PollTimeout = 30 * time.Second
+	}
+	return &Bot{
+		app:    app,
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.PollTimeout + 10*time.Second},
+	}
+}
+
+// Register добавляет опрос обновлений и рассылку дайджеста в планировщик, чтобы
+// при нескольких репликах они выполнялись под общей блокировкой задач.
+func (b *Bot) Register(s *scheduler.Scheduler) error {
+	if err := s.Add(PollJob, pollSchedule, 0, b.pollLoop); err != nil {
+		return err
+	}
+	if b.cfg.DigestHour >= 0 {
+		spec := fmt.Sprintf("0 %d * * *", b.cfg.DigestHour)
+		if err := s.Add(DigestJob, spec, 5*time.Minute, b.sendDigest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pollLoop получает обновления до отмены ctx.
+func (b *Bot) pollLoop(ctx context.Context) error {
+	slog.InfoContext(ctx, "Telegram-бот получает обновления", "chats", len(b.cfg.AllowedChats))
+	for ctx.Err() == nil {
+		if err := b.poll(ctx); err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "Ошибка получения обновлений Telegram", "error", err)
+			sleep(ctx, 5*time.Second)
+		}
+	}
+	return nil
+}
+
+// sleep ждёт d или отмены ctx.
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+type update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *message `json:"message"`
+}
+
+type message struct {
+	Text string `json:"text"`
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+func (b *Bot) poll(ctx context.Context) error {
+	var updates []update
+	err := b.call(ctx, "getUpdates", map[string]any{
+		"offset":          b.offset,
+		"timeout":         int(b.cfg.PollTimeout.Seconds()),
+		"allowed_updates": []string{"message"},
+	}, &updates)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range updates {
+		b.offset = u.UpdateID + 1
+		if u.Message == nil || u.Message.Text == "" {
+			continue
+		}
+		chatID := u.Message.Chat.ID
+		if !b.allowed(chatID) {
+			slog.Warn("Сообщение из неразрешённого чата проигнорировано", "chat_id", chatID)
+			continue
+		}
+		// Команда обрабатывается как HTTP-запрос: со своим ID в логах и вызовах API.
+		msgCtx := logging.WithRequestID(ctx, logging.NewRequestID())
+		slog.DebugContext(msgCtx, "Команда Telegram", "chat_id", chatID, "text", u.Message.Text)
+		if err := b.send(msgCtx, chatID, b.reply(msgCtx, u.Message.Text)); err != nil {
+			slog.WarnContext(msgCtx, "Не удалось отправить сообщение в Telegram", "chat_id", chatID, "error", err)
+		}
+	}
+	return nil
+}
+
+func (b *Bot) allowed(chatID int64) bool {
+	for _, id := range b.cfg.AllowedChats {
+		if id == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+// sendDigest отправляет дайджест во все разрешённые чаты. Ошибка отправки в один чат
+// не мешает остальным, но все ошибки возвращаются, чтобы запуск задачи считался неудачным.
+func (b *Bot) sendDigest(ctx context.Context) error {
+	text := b.digest(ctx)
+	var errs []error
+	for _, chatID := range b.cfg.AllowedChats {
+		if err := b.send(ctx, chatID, text); err != nil {
+			errs = append(errs, fmt.Errorf("чат %d: %w", chatID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *Bot) send(ctx context.Context, chatID int64, text string) error {
+	return b.call(ctx, "sendMessage", map[string]any{
+		"chat_id": chatID,
+		"text":    text,
+	}, nil)
+}
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+}
+
+// call вызывает метод Bot API и разбирает поле result в out.
+func (b *Bot) call(ctx context.Context, method string, params any, out any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimRight(b.cfg.APIURL, "/") + "/bot" + b.cfg.Token + "/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		// url.Error содержит адрес с токеном, в лог попадает только причина.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var res apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	if !res.OK {
+		return fmt.Errorf("%s: %s", method, res.Description)
+	}
+	if out != nil {
+		return json.Unmarshal(res.Result, out)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tinvest_report/internal/models"
+	"tinvest_report/internal/service"
+)
+
+const testToken = "123:secret"
+
+// fakeReports возвращает фиксированные отчёты вместо расчёта по счёту.
+type fakeReports struct{}
+
+func (fakeReports) BuildSummary(context.Context, string) (service.Summary, error) {
+	return service.Summary{PortfolioValue: 1500, NetStockProfit: 250.5}, nil
+}
+
+func (fakeReports) GetAllocation(context.Context, string) (models.Allocation, error) {
+	return models.Allocation{Total: 2000, Cash: 500, Positions: []models.AllocationPosition{
+		{FIGI: "BBG004730N88", Ticker: "SBER", Quantity: 10, Value: 1500, Weight: 0.75},
+	}}, nil
+}
+
+func (fakeReports) InstrumentPnL(_ context.Context, ticker string) (models.InstrumentPnL, error) {
+	if ticker != "SBER" {
+		return models.InstrumentPnL{}, service.ErrUnknownTicker
+	}
+	return models.InstrumentPnL{Ticker: "SBER", Name: "Сбербанк", Quantity: 10, NetProfit: 42}, nil
+}
+
+// stubAPI — заглушка Bot API: отдаёт заданные обновления и запоминает отправленные сообщения.
+type stubAPI struct {
+	t       *testing.T
+	updates []update
+	// failChat — чат, отправка в который завершается ошибкой API.
+	failChat int64
+
+	mu      sync.Mutex
+	offsets []int64
+	sent    map[int64][]string
+}
+
+func newStubAPI(t *testing.T) (*stubAPI, *httptest.Server) {
+	api := &stubAPI{t: t, sent: make(map[int64][]string)}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	return api, srv
+}
+
+func (s *stubAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method, ok := strings.CutPrefix(r.URL.Path, "/bot"+testToken+"/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	var params struct {
+		Offset int64  `json:"offset"`
+		ChatID int64  `json:"chat_id"`
+		Text   string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		s.t.Errorf("%s: %v", method, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch method {
+	case "getUpdates":
+		s.offsets = append(s.offsets, params.Offset)
+		result, _ := json.Marshal(s.updates)
+		json.NewEncoder(w).Encode(apiResponse{OK: true, Result: result})
+	case "sendMessage":
+		if params.ChatID == s.failChat {
+			json.NewEncoder(w).Encode(apiResponse{Description: "Forbidden: bot was blocked by the user"})
+			return
+		}
+		s.sent[params.ChatID] = append(s.sent[params.ChatID], params.Text)
+		json.NewEncoder(w).Encode(apiResponse{OK: true, Result: json.RawMessage(`{}`)})
+	default:
+		json.NewEncoder(w).Encode(apiResponse{Description: "Not Found: method not found"})
+	}
+}
+
+func textUpdate(id, chatID int64, text string) update {
+	u := update{UpdateID: id, Message: &message{Text: text}}
+	u.Message.Chat.ID = chatID
+	return u
+}
+
+func newTestBot(srv *httptest.Server, chats ...int64) *Bot {
+	return NewBot(fakeReports{}, Config{
+		Token:        testToken,
+		APIURL:       srv.URL,
+		AllowedChats: chats,
+		PollTimeout:  time.Second,
+	})
+}
+
+func TestPollAnswersOnlyAllowedChats(t *testing.T) {
+	api, srv := newStubAPI(t)
+	api.updates = []update{
+		textUpdate(10, 1, "/summary"),
+		textUpdate(11, 2, "/summary"),
+		{UpdateID: 12},
+	}
+	b := newTestBot(srv, 1)
+
+	if err := b.poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.sent[1]) != 1 || !strings.Contains(api.sent[1][0], "1500.00 ₽") {
+		t.Errorf("allowed chat got %q, want the summary", api.sent[1])
+	}
+	if len(api.sent[2]) != 0 {
+		t.Errorf("chat outside the allow-list got %q", api.sent[2])
+	}
+
+	// Следующий запрос подтверждает все полученные обновления, включая пропущенные.
+	api.updates = nil
+	if err := b.poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.offsets) != 2 || api.offsets[0] != 0 || api.offsets[1] != 13 {
+		t.Errorf("getUpdates offsets = %v, want [0 13]", api.offsets)
+	}
+}
+
+func TestReply(t *testing.T) {
+	b := NewBot(fakeReports{}, Config{})
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"/summary", "Чистая прибыль: 250.50 ₽"},
+		{"/summary@tinvest_bot", "Стоимость портфеля: 1500.00 ₽"},
+		{"/portfolio", "SBER — 10 шт., 1500.00 ₽ (75.0%)"},
+		{"/pnl", "Укажите тикер"},
+		{"/pnl SBER", "Результат: 42.00 ₽"},
+		{"/pnl GAZP", "Тикер GAZP не найден"},
+		{"/start", "Команды:"},
+		{"   ", "Команды:"},
+	}
+	for _, tt := range tests {
+		if got := b.reply(context.Background(), tt.text); !strings.Contains(got, tt.want) {
+			t.Errorf("reply(%q) = %q, want it to contain %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSendDigestReturnsDeliveryErrors(t *testing.T) {
+	api, srv := newStubAPI(t)
+	api.failChat = 2
+	b := newTestBot(srv, 1, 2, 3)
+
+	err := b.sendDigest(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "чат 2") || !strings.Contains(err.Error(), "blocked") {
+		t.Errorf("sendDigest error = %v, want the failure for chat 2", err)
+	}
+	for _, chatID := range []int64{1, 3} {
+		if len(api.sent[chatID]) != 1 || !strings.HasPrefix(api.sent[chatID][0], "📅 Ежедневный отчёт") {
+			t.Errorf("chat %d got %q, want the digest", chatID, api.sent[chatID])
+		}
+	}
+
+	api.failChat = 0
+	if err := b.sendDigest(context.Background()); err != nil {
+		t.Errorf("sendDigest = %v, want nil when every chat got the digest", err)
+	}
+}
+
+func TestCallHidesToken(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	b := newTestBot(srv)
+
+	err := b.call(context.Background(), "getMe", struct{}{}, nil)
+	if err == nil || strings.Contains(err.Error(), testToken) {
+		t.Errorf("call error = %v, want an error without the token", err)
+	}
+
+	_, srv = newStubAPI(t)
+	b = newTestBot(srv)
+	err = b.call(context.Background(), "getMe", struct{}{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "method not found") {
+		t.Errorf("call error = %v, want the API description", err)
+	}
+}
@@ -0,0 +1,112 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"tinvest_report/internal/service"
+)
+
+const helpText = `Команды:
+/summary — отчёт по счёту
+/portfolio — позиции и их доли
+/pnl TICKER — результат по инструменту`
+
+// maxPortfolioLines — сколько крупнейших позиций показывать в /portfolio.
+const maxPortfolioLines = 30
+
+// reply возвращает ответ на команду.
+func (b *Bot) reply(ctx context.Context, text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return helpText
+	}
+	// В группах команда приходит как /summary@bot_name.
+	command, _, _ := strings.Cut(fields[0], "@")
+
+	switch command {
+	case "/summary":
+		return b.summaryText(ctx)
+	case "/portfolio":
+		return b.portfolioText(ctx)
+	case "/pnl":
+		if len(fields) < 2 {
+			return "Укажите тикер: /pnl SBER"
+		}
+		return b.pnlText(ctx, fields[1])
+	default:
+		return helpText
+	}
+}
+
+func (b *Bot) digest(ctx context.Context) string {
+	return "📅 Ежедневный отчёт\n\n" + b.summaryText(ctx)
+}
+
+func (b *Bot) summaryText(ctx context.Context) string {
+	s, err := b.app.BuildSummary(ctx, "")
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка расчёта отчёта для Telegram", "error", err)
+		return "Не удалось рассчитать отчёт"
+	}
+	text := fmt.Sprintf(
+		"Стоимость портфеля: %s\nЧистая прибыль: %s\n\nПополнения: %s\nВыводы: %s\nПокупки: %s\nПродажи: %s\nКомиссии: %s\nНалоги: %s",
+		money(s.PortfolioValue), money(s.NetStockProfit),
+		money(s.TotalInput), money(s.TotalOutput), money(s.TotalBuys), money(s.TotalSells),
+		money(s.Commissions), money(s.Taxes),
+	)
+	if s.Incomplete {
+		text += fmt.Sprintf("\n\n⚠️ Отчёт неполный: не указана стоимость приобретения введённых бумаг (операций: %d)", len(s.MissingCostBasis))
+	}
+	return text
+}
+
+func (b *Bot) portfolioText(ctx context.Context) string {
+	alloc, err := b.app.GetAllocation(ctx, "")
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка расчёта структуры портфеля для Telegram", "error", err)
+		return "Не удалось получить портфель"
+	}
+	if len(alloc.Positions) == 0 {
+		return "Открытых позиций нет\nДеньги: " + money(alloc.Cash)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Всего: %s\nДеньги: %s\n\n", money(alloc.Total), money(alloc.Cash))
+	for i, p := range alloc.Positions {
+		if i == maxPortfolioLines {
+			fmt.Fprintf(&sb, "… и ещё %d", len(alloc.Positions)-i)
+			break
+		}
+		name := p.Ticker
+		if name == "" {
+			name = p.FIGI
+		}
+		fmt.Fprintf(&sb, "%s — %g шт., %s (%.1f%%)\n", name, p.Quantity, money(p.Value), p.Weight*100)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func (b *Bot) pnlText(ctx context.Context, ticker string) string {
+	pnl, err := b.app.InstrumentPnL(ctx, ticker)
+	if errors.Is(err, service.ErrUnknownTicker) {
+		return "Тикер " + strings.ToUpper(ticker) + " не найден в операциях счёта"
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка расчёта результата по инструменту для Telegram", "ticker", ticker, "error", err)
+		return "Не удалось рассчитать результат"
+	}
+	return fmt.Sprintf(
+		"%s (%s)\nПозиция: %g шт., %s\n\nПокупки: %s\nПродажи: %s\nКомиссии: %s\nНалоги: %s\nРезультат: %s",
+		pnl.Ticker, pnl.Name, pnl.Quantity, money(pnl.Value),
+		money(pnl.TotalBuys), money(pnl.TotalSells), money(pnl.Commissions), money(pnl.Taxes),
+		money(pnl.NetProfit),
+	)
+}
+
+func money(v float64) string {
+	return fmt.Sprintf("%.2f ₽", v)
+}
@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// ServiceName — имя сервиса в трассах по умолчанию; заменяется переменной OTEL_SERVICE_NAME.
+const ServiceName = "tinvest_report"
+
+// Setup настраивает глобальный TracerProvider по переменным окружения:
+// OTEL_TRACES_EXPORTER — otlp, console (вывод в stdout) или none (по умолчанию, трассировка выключена);
+// OTEL_EXPORTER_OTLP_PROTOCOL — http/protobuf (по умолчанию) или grpc. Адрес коллектора, заголовки
+// и семплирование задаются стандартными OTEL_EXPORTER_OTLP_* и OTEL_TRACES_SAMPLER.
+// Возвращает функцию, которая при остановке выгружает накопленные спаны.
+func Setup(ctx context.Context, version string) (func(context.Context) error, error) {
+	exporter, err := newExporter(ctx, strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")))
+	if err != nil || exporter == nil {
+		return func(context.Context) error { return nil }, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(ServiceName), semconv.ServiceVersion(version)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, name string) (sdktrace.SpanExporter, error) {
+	switch name {
+	case "", "none":
+		return nil, nil
+	case "console", "stdout":
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "otlp":
+		protocol := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+		if protocol == "" {
+			protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+		}
+		switch protocol {
+		case "", "http/protobuf":
+			return otlptracehttp.New(ctx)
+		case "grpc":
+			return otlptracegrpc.New(ctx)
+		}
+		return nil, fmt.Errorf("неподдерживаемый протокол OTLP %s, ожидается http/protobuf или grpc", protocol)
+	}
+	return nil, fmt.Errorf("неизвестный OTEL_TRACES_EXPORTER=%s, ожидается otlp, console или none", name)
+}
+
+// untracedRoutes — служебные маршруты, которые опрашиваются часто и не несут пользы в трассах.
+var untracedRoutes = map[string]bool{"/metrics": true, "/healthz": true, "/readyz": true}
+
+// HTTPHandler создаёт спан на каждый входящий запрос к mux. Спан называется по шаблону
+// маршрута, а не по пути, чтобы запросы к /alerts/1 и /alerts/2 группировались вместе.
+func HTTPHandler(mux *http.ServeMux, next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			_, route := mux.Handler(r)
+			if route == "" {
+				route = "unmatched"
+			}
+			return r.Method + " " + route
+		}),
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return !untracedRoutes[r.URL.Path]
+		}),
+	)
+}